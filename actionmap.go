@@ -0,0 +1,200 @@
+package nyuuryoku
+
+import (
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ActionMap binds named actions to keys, mouse buttons, gamepad buttons and gamepad axes.
+//
+// An action is pressed while any of its bindings is pressed.
+// ActionMap reads input through the wrappers, so the input can be replaced by their setters.
+// Any of the wrappers may be nil, in which case bindings of the device are never pressed.
+//
+// Call Update exactly once at the beginning of every Update of the game,
+// in the same way as inpututil updates its state before every Update.
+type ActionMap struct {
+	reader  bindingReader
+	actions map[string]*actionState
+	names   []string
+
+	composites     map[string]*compositeState
+	compositeNames []string
+
+	tick int
+}
+
+type actionState struct {
+	bindings     []Binding
+	curve        ResponseCurve
+	value        float64
+	duration     int
+	prevDuration int
+}
+
+func NewActionMap(keyboard *Keyboard, mouse *Mouse, gamepad *Gamepad) *ActionMap {
+	return &ActionMap{
+		reader: bindingReader{
+			keyboard: keyboard,
+			mouse:    mouse,
+			gamepad:  gamepad,
+		},
+		actions:    make(map[string]*actionState),
+		composites: make(map[string]*compositeState),
+	}
+}
+
+// SetGamepadIDs restricts the gamepads that gamepad bindings are read from, such as the gamepad of one player.
+// With no IDs, gamepad bindings are read from all the connected gamepads.
+func (m *ActionMap) SetGamepadIDs(ids ...ebiten.GamepadID) {
+	m.reader.setGamepadIDs(ids)
+}
+
+func (m *ActionMap) action(name string) *actionState {
+	a, ok := m.actions[name]
+	if !ok {
+		a = &actionState{}
+		m.actions[name] = a
+		m.names = append(m.names, name)
+	}
+	return a
+}
+
+// Bind adds bindings to the action. Bindings that the action already has are ignored.
+func (m *ActionMap) Bind(action string, bindings ...Binding) {
+	a := m.action(action)
+	for _, b := range bindings {
+		if !slices.Contains(a.bindings, b) {
+			a.bindings = append(a.bindings, b)
+		}
+	}
+}
+
+// Unbind removes a binding from the action. Axis bindings are removed only if their thresholds are also equal.
+func (m *ActionMap) Unbind(action string, binding Binding) {
+	a, ok := m.actions[action]
+	if !ok {
+		return
+	}
+	a.bindings = slices.DeleteFunc(a.bindings, func(b Binding) bool {
+		return b == binding
+	})
+}
+
+// SetBindings replaces all the bindings of the action.
+func (m *ActionMap) SetBindings(action string, bindings []Binding) {
+	a := m.action(action)
+	a.bindings = a.bindings[:0]
+	m.Bind(action, bindings...)
+}
+
+// Bindings returns the bindings of the action.
+func (m *ActionMap) Bindings(action string) []Binding {
+	a, ok := m.actions[action]
+	if !ok {
+		return nil
+	}
+	return slices.Clone(a.bindings)
+}
+
+// Actions returns the names of all the actions in the order they were added.
+func (m *ActionMap) Actions() []string {
+	return slices.Clone(m.names)
+}
+
+// SetValueCurve sets the response curve applied to Value of the action, such as for an analog trigger.
+// The curve does not change when the action is pressed.
+func (m *ActionMap) SetValueCurve(action string, curve ResponseCurve) {
+	m.action(action).curve = curve
+}
+
+// ActionsBoundTo returns the names of the actions that have a binding triggered by the same physical input as b.
+func (m *ActionMap) ActionsBoundTo(b Binding) []string {
+	var names []string
+	for _, name := range m.names {
+		if slices.ContainsFunc(m.actions[name].bindings, b.overlaps) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// UnbindOverlapping removes the bindings of the action triggered by the same physical input as b,
+// such as the conflicts reported by ActionsBoundTo. Axis bindings in the same direction are removed regardless of their thresholds.
+func (m *ActionMap) UnbindOverlapping(action string, b Binding) {
+	a, ok := m.actions[action]
+	if !ok {
+		return
+	}
+	a.bindings = slices.DeleteFunc(a.bindings, b.overlaps)
+}
+
+// Update reads the state of all the bindings and advances the actions and the composite axes by one tick.
+func (m *ActionMap) Update() {
+	m.tick++
+
+	for _, name := range m.names {
+		a := m.actions[name]
+
+		pressed := false
+		value := 0.0
+		for _, b := range a.bindings {
+			value = max(value, m.reader.value(b))
+			if m.reader.isPressed(b) {
+				pressed = true
+			}
+		}
+
+		a.value = a.curve.Apply(value)
+		a.prevDuration = a.duration
+		if pressed {
+			a.duration++
+		} else {
+			a.duration = 0
+		}
+	}
+
+	for _, name := range m.compositeNames {
+		m.updateComposite(m.composites[name])
+	}
+}
+
+// IsPressed reports whether the action is pressed.
+func (m *ActionMap) IsPressed(action string) bool {
+	return m.PressDuration(action) > 0
+}
+
+// IsJustPressed reports whether the action is pressed at the current tick and was not at the previous tick.
+func (m *ActionMap) IsJustPressed(action string) bool {
+	return m.PressDuration(action) == 1
+}
+
+// IsJustReleased reports whether the action is not pressed at the current tick and was at the previous tick.
+func (m *ActionMap) IsJustReleased(action string) bool {
+	a, ok := m.actions[action]
+	if !ok {
+		return false
+	}
+	return a.duration == 0 && a.prevDuration > 0
+}
+
+// PressDuration returns how many ticks the action has been pressed.
+func (m *ActionMap) PressDuration(action string) int {
+	a, ok := m.actions[action]
+	if !ok {
+		return 0
+	}
+	return a.duration
+}
+
+// Value returns how much the action is pressed, in the range [0, 1].
+// Buttons count as 0 or 1, and axes count as the amount in the direction of their thresholds.
+// The largest value among the bindings is returned.
+func (m *ActionMap) Value(action string) float64 {
+	a, ok := m.actions[action]
+	if !ok {
+		return 0
+	}
+	return a.value
+}
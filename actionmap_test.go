@@ -0,0 +1,177 @@
+package nyuuryoku_test
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/noppikinatta/nyuuryoku"
+)
+
+// testDevices drives an ActionMap with virtual devices attached to its wrappers.
+type testDevices struct {
+	keyboard *nyuuryoku.VirtualKeyboard
+	mouse    *nyuuryoku.VirtualMouse
+	gamepad  *nyuuryoku.VirtualGamepad
+	pad      ebiten.GamepadID
+}
+
+func newTestActionMap() (*nyuuryoku.ActionMap, *testDevices) {
+	k, m, g := nyuuryoku.NewKeyboard(), nyuuryoku.NewMouse(), nyuuryoku.NewGamepad()
+	d := &testDevices{
+		keyboard: nyuuryoku.NewVirtualKeyboard(),
+		mouse:    nyuuryoku.NewVirtualMouse(),
+		gamepad:  nyuuryoku.NewVirtualGamepad(),
+	}
+	d.keyboard.Attach(k)
+	d.mouse.Attach(m)
+	d.gamepad.Attach(g)
+	d.pad = d.gamepad.Connect(nyuuryoku.StandardGamepadProfile)
+	return nyuuryoku.NewActionMap(k, m, g), d
+}
+
+func (d *testDevices) tick(m *nyuuryoku.ActionMap) {
+	d.keyboard.Tick()
+	d.mouse.Tick()
+	d.gamepad.Tick()
+	m.Update()
+}
+
+// actionTick is the input at a tick and the expected state of the action after it.
+type actionTick struct {
+	input       func(d *testDevices)
+	pressed     bool
+	justPressed bool
+	value       float64
+}
+
+func TestActionMap(t *testing.T) {
+	const action = "action"
+	leftStick := ebiten.StandardGamepadAxisLeftStickHorizontal
+
+	tests := []struct {
+		name     string
+		bindings []nyuuryoku.Binding
+		ticks    []actionTick
+	}{
+		{
+			name:     "key",
+			bindings: []nyuuryoku.Binding{nyuuryoku.KeyBinding(ebiten.KeySpace)},
+			ticks: []actionTick{
+				{},
+				{input: func(d *testDevices) { d.keyboard.Press(ebiten.KeySpace) }, pressed: true, justPressed: true, value: 1},
+				{pressed: true, value: 1},
+				{input: func(d *testDevices) { d.keyboard.Release(ebiten.KeySpace) }},
+				{input: func(d *testDevices) { d.keyboard.Press(ebiten.KeyA) }},
+			},
+		},
+		{
+			name:     "mouse button",
+			bindings: []nyuuryoku.Binding{nyuuryoku.MouseButtonBinding(ebiten.MouseButtonRight)},
+			ticks: []actionTick{
+				{input: func(d *testDevices) { d.mouse.Press(ebiten.MouseButtonLeft) }},
+				{input: func(d *testDevices) { d.mouse.Press(ebiten.MouseButtonRight) }, pressed: true, justPressed: true, value: 1},
+				{input: func(d *testDevices) { d.mouse.Release(ebiten.MouseButtonRight) }},
+			},
+		},
+		{
+			name:     "gamepad button",
+			bindings: []nyuuryoku.Binding{nyuuryoku.GamepadButtonBinding(2)},
+			ticks: []actionTick{
+				{input: func(d *testDevices) { d.gamepad.PressButton(d.pad, 2) }, pressed: true, justPressed: true, value: 1},
+				{pressed: true, value: 1},
+				{input: func(d *testDevices) { d.gamepad.ReleaseButton(d.pad, 2) }},
+			},
+		},
+		{
+			name:     "standard gamepad button",
+			bindings: []nyuuryoku.Binding{nyuuryoku.StandardGamepadButtonBinding(ebiten.StandardGamepadButtonRightBottom)},
+			ticks: []actionTick{
+				{input: func(d *testDevices) { d.gamepad.PressStandardButton(d.pad, ebiten.StandardGamepadButtonRightBottom) }, pressed: true, justPressed: true, value: 1},
+				{input: func(d *testDevices) { d.gamepad.ReleaseStandardButton(d.pad, ebiten.StandardGamepadButtonRightBottom) }},
+			},
+		},
+		{
+			name:     "gamepad axis",
+			bindings: []nyuuryoku.Binding{nyuuryoku.GamepadAxisBinding(1, 0.5)},
+			ticks: []actionTick{
+				{input: func(d *testDevices) { d.gamepad.SetAxisValue(d.pad, 1, 0.25) }, value: 0.25},
+				{input: func(d *testDevices) { d.gamepad.SetAxisValue(d.pad, 1, 0.5) }, pressed: true, justPressed: true, value: 0.5},
+				{input: func(d *testDevices) { d.gamepad.SetAxisValue(d.pad, 1, 1) }, pressed: true, value: 1},
+				{input: func(d *testDevices) { d.gamepad.SetAxisValue(d.pad, 1, -1) }},
+			},
+		},
+		{
+			name:     "negative standard gamepad axis",
+			bindings: []nyuuryoku.Binding{nyuuryoku.StandardGamepadAxisBinding(leftStick, -0.5)},
+			ticks: []actionTick{
+				{input: func(d *testDevices) { d.gamepad.SetStandardAxisValue(d.pad, leftStick, 0.75) }},
+				{input: func(d *testDevices) { d.gamepad.SetStandardAxisValue(d.pad, leftStick, -0.25) }, value: 0.25},
+				{input: func(d *testDevices) { d.gamepad.SetStandardAxisValue(d.pad, leftStick, -0.75) }, pressed: true, justPressed: true, value: 0.75},
+				{input: func(d *testDevices) { d.gamepad.SetStandardAxisValue(d.pad, leftStick, 0) }},
+			},
+		},
+		{
+			name:     "standard gamepad axis with threshold 0",
+			bindings: []nyuuryoku.Binding{nyuuryoku.StandardGamepadAxisBinding(leftStick, 0)},
+			ticks: []actionTick{
+				{},
+				{input: func(d *testDevices) { d.gamepad.SetStandardAxisValue(d.pad, leftStick, 0.25) }, pressed: true, justPressed: true, value: 0.25},
+				{input: func(d *testDevices) { d.gamepad.SetStandardAxisValue(d.pad, leftStick, -0.25) }},
+			},
+		},
+		{
+			name: "largest of bindings",
+			bindings: []nyuuryoku.Binding{
+				nyuuryoku.KeyBinding(ebiten.KeyArrowRight),
+				nyuuryoku.StandardGamepadAxisBinding(leftStick, 0.5),
+			},
+			ticks: []actionTick{
+				{input: func(d *testDevices) { d.gamepad.SetStandardAxisValue(d.pad, leftStick, 0.25) }, value: 0.25},
+				{input: func(d *testDevices) { d.keyboard.Press(ebiten.KeyArrowRight) }, pressed: true, justPressed: true, value: 1},
+				{input: func(d *testDevices) { d.gamepad.SetStandardAxisValue(d.pad, leftStick, 0.75) }, pressed: true, value: 1},
+				{input: func(d *testDevices) { d.keyboard.Release(ebiten.KeyArrowRight) }, pressed: true, value: 0.75},
+				{input: func(d *testDevices) { d.gamepad.SetStandardAxisValue(d.pad, leftStick, 0) }},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, d := newTestActionMap()
+			m.Bind(action, tt.bindings...)
+
+			// The first tick connects the gamepad.
+			d.tick(m)
+
+			for i, tick := range tt.ticks {
+				if tick.input != nil {
+					tick.input(d)
+				}
+				d.tick(m)
+
+				if got := m.IsPressed(action); got != tick.pressed {
+					t.Errorf("tick %d: IsPressed: got %v, want %v", i, got, tick.pressed)
+				}
+				if got := m.IsJustPressed(action); got != tick.justPressed {
+					t.Errorf("tick %d: IsJustPressed: got %v, want %v", i, got, tick.justPressed)
+				}
+				if got := m.Value(action); got != tick.value {
+					t.Errorf("tick %d: Value: got %v, want %v", i, got, tick.value)
+				}
+			}
+		})
+	}
+}
+
+func TestActionMapUnknownAction(t *testing.T) {
+	m, d := newTestActionMap()
+	m.Bind("jump", nyuuryoku.KeyBinding(ebiten.KeySpace))
+	d.keyboard.Press(ebiten.KeySpace)
+	d.tick(m)
+
+	if m.IsPressed("run") || m.IsJustPressed("run") || m.Value("run") != 0 {
+		t.Error("an unknown action is pressed")
+	}
+	if !m.IsPressed("jump") {
+		t.Error("IsPressed: got false, want true")
+	}
+}
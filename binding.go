@@ -0,0 +1,341 @@
+package nyuuryoku
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// BindingKind is the kind of a physical input of a Binding.
+type BindingKind int
+
+const (
+	BindingKindNone BindingKind = iota
+	BindingKindKey
+	BindingKindMouseButton
+	BindingKindGamepadButton
+	BindingKindStandardGamepadButton
+	BindingKindGamepadAxis
+	BindingKindStandardGamepadAxis
+)
+
+// Binding is a physical input that an action can be bound to.
+//
+// Code is the key, the button or the axis of the kind.
+// Threshold is used only by axis bindings. The sign of Threshold is the direction of the axis:
+// a positive threshold is pressed when the axis value is greater than or equal to it,
+// and a negative threshold is pressed when the axis value is less than or equal to it.
+// A threshold of 0 is the positive direction, pressed by any positive value.
+// Thresholds are in the range [-1, 1].
+//
+// Binding is comparable, so it can be used as a map key.
+type Binding struct {
+	Kind      BindingKind
+	Code      int
+	Threshold float64
+}
+
+func KeyBinding(key ebiten.Key) Binding {
+	return Binding{Kind: BindingKindKey, Code: int(key)}
+}
+
+func MouseButtonBinding(button ebiten.MouseButton) Binding {
+	return Binding{Kind: BindingKindMouseButton, Code: int(button)}
+}
+
+func GamepadButtonBinding(button ebiten.GamepadButton) Binding {
+	return Binding{Kind: BindingKindGamepadButton, Code: int(button)}
+}
+
+func StandardGamepadButtonBinding(button ebiten.StandardGamepadButton) Binding {
+	return Binding{Kind: BindingKindStandardGamepadButton, Code: int(button)}
+}
+
+func GamepadAxisBinding(axis int, threshold float64) Binding {
+	return Binding{Kind: BindingKindGamepadAxis, Code: axis, Threshold: threshold}
+}
+
+func StandardGamepadAxisBinding(axis ebiten.StandardGamepadAxis, threshold float64) Binding {
+	return Binding{Kind: BindingKindStandardGamepadAxis, Code: int(axis), Threshold: threshold}
+}
+
+// IsAxis reports whether b is bound to an axis.
+func (b Binding) IsAxis() bool {
+	return b.Kind == BindingKindGamepadAxis || b.Kind == BindingKindStandardGamepadAxis
+}
+
+// IsGamepad reports whether b is bound to an input of a gamepad.
+func (b Binding) IsGamepad() bool {
+	switch b.Kind {
+	case BindingKindGamepadButton, BindingKindStandardGamepadButton, BindingKindGamepadAxis, BindingKindStandardGamepadAxis:
+		return true
+	}
+	return false
+}
+
+// String returns the name of b, such as "KeyA", "MouseButtonLeft", "GamepadButton3",
+// "StandardGamepadButtonRightBottom", "axis:GamepadAxis2:-0.5" and "axis:StandardGamepadAxisLeftStickHorizontal:+0.5".
+func (b Binding) String() string {
+	switch b.Kind {
+	case BindingKindKey:
+		return "Key" + keyName(ebiten.Key(b.Code))
+	case BindingKindMouseButton:
+		return "MouseButton" + mouseButtonName(ebiten.MouseButton(b.Code))
+	case BindingKindGamepadButton:
+		return "GamepadButton" + strconv.Itoa(b.Code)
+	case BindingKindStandardGamepadButton:
+		return "StandardGamepadButton" + standardGamepadButtonName(ebiten.StandardGamepadButton(b.Code))
+	case BindingKindGamepadAxis:
+		return "axis:GamepadAxis" + strconv.Itoa(b.Code) + ":" + formatThreshold(b.Threshold)
+	case BindingKindStandardGamepadAxis:
+		return "axis:StandardGamepadAxis" + standardGamepadAxisName(ebiten.StandardGamepadAxis(b.Code)) + ":" + formatThreshold(b.Threshold)
+	}
+	return "None"
+}
+
+func formatThreshold(t float64) string {
+	if t == 0 {
+		// Avoid "-0", which is the positive direction.
+		return "+0"
+	}
+	s := strconv.FormatFloat(t, 'f', -1, 64)
+	if t >= 0 {
+		s = "+" + s
+	}
+	return s
+}
+
+// ParseBinding parses a name returned by Binding.String.
+func ParseBinding(name string) (Binding, error) {
+	b, err := parseBinding(name)
+	if err != nil {
+		return Binding{}, fmt.Errorf("nyuuryoku: %w", err)
+	}
+	return b, nil
+}
+
+func parseBinding(name string) (Binding, error) {
+	if s, ok := strings.CutPrefix(name, "axis:"); ok {
+		return parseAxisBinding(name, s)
+	}
+	if s, ok := strings.CutPrefix(name, "StandardGamepadButton"); ok {
+		if b, ok := parseStandardGamepadButtonName(s); ok {
+			return StandardGamepadButtonBinding(b), nil
+		}
+	} else if s, ok := strings.CutPrefix(name, "GamepadButton"); ok {
+		if b, err := strconv.Atoi(s); err == nil && b >= 0 && b <= int(ebiten.GamepadButtonMax) {
+			return GamepadButtonBinding(ebiten.GamepadButton(b)), nil
+		}
+	} else if s, ok := strings.CutPrefix(name, "MouseButton"); ok {
+		if b, ok := parseMouseButtonName(s); ok {
+			return MouseButtonBinding(b), nil
+		}
+	} else if s, ok := strings.CutPrefix(name, "Key"); ok {
+		if k, ok := parseKeyName(s); ok {
+			return KeyBinding(k), nil
+		}
+	}
+	return Binding{}, fmt.Errorf("unknown input: %q", name)
+}
+
+func parseAxisBinding(name, s string) (Binding, error) {
+	axis, t, ok := strings.Cut(s, ":")
+	if !ok {
+		return Binding{}, fmt.Errorf("axis without a threshold: %q", name)
+	}
+	threshold, err := strconv.ParseFloat(t, 64)
+	if err != nil || !(threshold >= -1 && threshold <= 1) {
+		return Binding{}, fmt.Errorf("invalid axis threshold: %q", name)
+	}
+	if a, ok := strings.CutPrefix(axis, "StandardGamepadAxis"); ok {
+		if a, ok := parseStandardGamepadAxisName(a); ok {
+			return StandardGamepadAxisBinding(a, threshold), nil
+		}
+	} else if a, ok := strings.CutPrefix(axis, "GamepadAxis"); ok {
+		if a, err := strconv.Atoi(a); err == nil && a >= 0 {
+			return GamepadAxisBinding(a, threshold), nil
+		}
+	}
+	return Binding{}, fmt.Errorf("unknown axis: %q", name)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b Binding) MarshalText() ([]byte, error) {
+	if b.Kind == BindingKindNone {
+		return nil, errors.New("nyuuryoku: cannot marshal a binding without a kind")
+	}
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *Binding) UnmarshalText(text []byte) error {
+	v, err := ParseBinding(string(text))
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// bindingReader reads the state of bindings through the wrappers.
+// Gamepad bindings are read from the gamepads in gamepadIDs, or from all the connected gamepads if gamepadIDs is nil.
+type bindingReader struct {
+	keyboard   *Keyboard
+	mouse      *Mouse
+	gamepad    *Gamepad
+	gamepadIDs []ebiten.GamepadID
+	tmpIDs     []ebiten.GamepadID
+}
+
+// setGamepadIDs restricts the gamepads that gamepad bindings are read from. With no IDs, all the connected gamepads are read.
+func (r *bindingReader) setGamepadIDs(ids []ebiten.GamepadID) {
+	if len(ids) == 0 {
+		r.gamepadIDs = nil
+		return
+	}
+	r.gamepadIDs = slices.Clone(ids)
+}
+
+func (r *bindingReader) ids() []ebiten.GamepadID {
+	if r.gamepadIDs != nil {
+		return r.gamepadIDs
+	}
+	r.tmpIDs = r.gamepad.AppendIDs(r.tmpIDs[:0])
+	return r.tmpIDs
+}
+
+// value returns how much b is pressed, in the range [0, 1].
+// Buttons are 0 or 1, and axes are the amount in the direction of the threshold.
+func (r *bindingReader) value(b Binding) float64 {
+	switch b.Kind {
+	case BindingKindKey:
+		if r.keyboard != nil && r.keyboard.IsPressed(ebiten.Key(b.Code)) {
+			return 1
+		}
+	case BindingKindMouseButton:
+		if r.mouse != nil && r.mouse.IsPressed(ebiten.MouseButton(b.Code)) {
+			return 1
+		}
+	case BindingKindGamepadButton, BindingKindStandardGamepadButton, BindingKindGamepadAxis, BindingKindStandardGamepadAxis:
+		if r.gamepad == nil {
+			return 0
+		}
+		v := 0.0
+		for _, id := range r.ids() {
+			v = max(v, r.gamepadValue(id, b))
+		}
+		return v
+	}
+	return 0
+}
+
+func (r *bindingReader) gamepadValue(id ebiten.GamepadID, b Binding) float64 {
+	switch b.Kind {
+	case BindingKindGamepadButton:
+		if r.gamepad.IsButtonPressed(id, ebiten.GamepadButton(b.Code)) {
+			return 1
+		}
+	case BindingKindStandardGamepadButton:
+		if r.gamepad.IsStandardButtonPressed(id, ebiten.StandardGamepadButton(b.Code)) {
+			return 1
+		}
+	case BindingKindGamepadAxis:
+		return axisAmount(r.gamepad.AxisValue(id, b.Code), b.Threshold)
+	case BindingKindStandardGamepadAxis:
+		return axisAmount(r.gamepad.StandardAxisValue(id, ebiten.StandardGamepadAxis(b.Code)), b.Threshold)
+	}
+	return 0
+}
+
+// isPressed reports whether b is pressed.
+func (r *bindingReader) isPressed(b Binding) bool {
+	v := r.value(b)
+	if b.IsAxis() {
+		return v > 0 && v >= abs(b.Threshold)
+	}
+	return v > 0
+}
+
+// isJustPressed reports whether b is just pressed by the Just* functions of the wrappers.
+// Axes have no just-pressed state, so isJustPressed is always false for them.
+func (r *bindingReader) isJustPressed(b Binding) bool {
+	switch b.Kind {
+	case BindingKindKey:
+		return r.keyboard != nil && r.keyboard.IsJustPressed(ebiten.Key(b.Code))
+	case BindingKindMouseButton:
+		return r.mouse != nil && r.mouse.IsJustPressed(ebiten.MouseButton(b.Code))
+	case BindingKindGamepadButton, BindingKindStandardGamepadButton:
+		if r.gamepad == nil {
+			return false
+		}
+		for _, id := range r.ids() {
+			if b.Kind == BindingKindGamepadButton && r.gamepad.IsButtonJustPressed(id, ebiten.GamepadButton(b.Code)) {
+				return true
+			}
+			if b.Kind == BindingKindStandardGamepadButton && r.gamepad.IsStandardButtonJustPressed(id, ebiten.StandardGamepadButton(b.Code)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pressDuration returns the press duration of b by the PressDuration functions of the wrappers.
+// Gamepad buttons return the longest duration among the gamepads. Axes have no press duration, so pressDuration is always 0 for them.
+func (r *bindingReader) pressDuration(b Binding) int {
+	switch b.Kind {
+	case BindingKindKey:
+		if r.keyboard != nil {
+			return r.keyboard.PressDuration(ebiten.Key(b.Code))
+		}
+	case BindingKindMouseButton:
+		if r.mouse != nil {
+			return r.mouse.PressDuration(ebiten.MouseButton(b.Code))
+		}
+	case BindingKindGamepadButton, BindingKindStandardGamepadButton:
+		if r.gamepad == nil {
+			return 0
+		}
+		d := 0
+		for _, id := range r.ids() {
+			if b.Kind == BindingKindGamepadButton {
+				d = max(d, r.gamepad.ButtonPressDuration(id, ebiten.GamepadButton(b.Code)))
+			} else {
+				d = max(d, r.gamepad.StandardButtonPressDuration(id, ebiten.StandardGamepadButton(b.Code)))
+			}
+		}
+		return d
+	}
+	return 0
+}
+
+// axisAmount returns the amount of value in the direction of threshold, in the range [0, 1].
+func axisAmount(value, threshold float64) float64 {
+	if threshold < 0 {
+		value = -value
+	}
+	return max(0, min(1, value))
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// overlaps reports whether b and other are triggered by the same physical input.
+// Axis bindings overlap when they are bound to the same direction of the same axis, regardless of their thresholds.
+func (b Binding) overlaps(other Binding) bool {
+	if b.Kind != other.Kind || b.Code != other.Code {
+		return false
+	}
+	if b.IsAxis() {
+		return (b.Threshold < 0) == (other.Threshold < 0)
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package nyuuryoku_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/noppikinatta/nyuuryoku"
+)
+
+func TestBindingNameRoundTrip(t *testing.T) {
+	tests := []struct {
+		binding nyuuryoku.Binding
+		name    string
+	}{
+		{binding: nyuuryoku.KeyBinding(ebiten.KeyA), name: "KeyA"},
+		{binding: nyuuryoku.KeyBinding(ebiten.KeyArrowUp), name: "KeyArrowUp"},
+		{binding: nyuuryoku.MouseButtonBinding(ebiten.MouseButtonLeft), name: "MouseButtonLeft"},
+		{binding: nyuuryoku.GamepadButtonBinding(3), name: "GamepadButton3"},
+		{binding: nyuuryoku.GamepadButtonBinding(ebiten.GamepadButtonMax), name: "GamepadButton31"},
+		{binding: nyuuryoku.StandardGamepadButtonBinding(ebiten.StandardGamepadButtonRightBottom), name: "StandardGamepadButtonRightBottom"},
+		{binding: nyuuryoku.GamepadAxisBinding(2, -0.5), name: "axis:GamepadAxis2:-0.5"},
+		{binding: nyuuryoku.GamepadAxisBinding(0, 1), name: "axis:GamepadAxis0:+1"},
+		{binding: nyuuryoku.GamepadAxisBinding(1, 0), name: "axis:GamepadAxis1:+0"},
+		{binding: nyuuryoku.StandardGamepadAxisBinding(ebiten.StandardGamepadAxisLeftStickHorizontal, 0.5), name: "axis:StandardGamepadAxisLeftStickHorizontal:+0.5"},
+		{binding: nyuuryoku.StandardGamepadAxisBinding(ebiten.StandardGamepadAxisRightStickVertical, -1), name: "axis:StandardGamepadAxisRightStickVertical:-1"},
+		{binding: nyuuryoku.StandardGamepadAxisBinding(ebiten.StandardGamepadAxisLeftStickVertical, 0), name: "axis:StandardGamepadAxisLeftStickVertical:+0"},
+		{binding: nyuuryoku.StandardGamepadAxisBinding(ebiten.StandardGamepadAxisLeftStickVertical, math.Copysign(0, -1)), name: "axis:StandardGamepadAxisLeftStickVertical:+0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.binding.String(); got != tt.name {
+				t.Errorf("String: got %q, want %q", got, tt.name)
+			}
+
+			got, err := nyuuryoku.ParseBinding(tt.name)
+			if err != nil {
+				t.Fatalf("ParseBinding: %v", err)
+			}
+			if got != tt.binding {
+				t.Errorf("ParseBinding: got %+v, want %+v", got, tt.binding)
+			}
+
+			text, err := tt.binding.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText: %v", err)
+			}
+			var b nyuuryoku.Binding
+			if err := b.UnmarshalText(text); err != nil {
+				t.Fatalf("UnmarshalText: %v", err)
+			}
+			if b != tt.binding {
+				t.Errorf("UnmarshalText: got %+v, want %+v", b, tt.binding)
+			}
+		})
+	}
+}
+
+func TestParseBindingErrors(t *testing.T) {
+	for _, name := range []string{
+		"",
+		"None",
+		"KeySpacebar",
+		"MouseButtonCenter",
+		"GamepadButton-1",
+		"GamepadButton32",
+		"StandardGamepadButtonTop",
+		"axis:GamepadAxis1",
+		"axis:GamepadAxis-1:+0.5",
+		"axis:GamepadAxis1:+1.5",
+		"axis:GamepadAxis1:-1.5",
+		"axis:GamepadAxis1:NaN",
+		"axis:GamepadAxis1:x",
+		"axis:StandardGamepadAxisLeftStick:+0.5",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if b, err := nyuuryoku.ParseBinding(name); err == nil {
+				t.Errorf("ParseBinding: got %+v, want an error", b)
+			}
+		})
+	}
+
+	var b nyuuryoku.Binding
+	if _, err := b.MarshalText(); err == nil {
+		t.Error("MarshalText of a binding without a kind: got no error")
+	}
+}
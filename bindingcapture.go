@@ -0,0 +1,282 @@
+package nyuuryoku
+
+import (
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// CaptureStatus is the status of a BindingCapture at a tick.
+type CaptureStatus int
+
+const (
+	// CaptureStatusNone means that nothing happened at the tick.
+	CaptureStatusNone CaptureStatus = iota
+	// CaptureStatusCaptured means that an input was captured and the capture finished.
+	CaptureStatusCaptured
+	// CaptureStatusCanceled means that a cancel key was pressed and the capture finished.
+	CaptureStatusCanceled
+	// CaptureStatusRejected means that an input was rejected because of conflicts and the capture continues.
+	CaptureStatusRejected
+)
+
+// CaptureResult is the result of BindingCapture.Update.
+type CaptureResult struct {
+	Status CaptureStatus
+
+	// Binding is the captured or rejected input.
+	Binding Binding
+
+	// Conflicts is the names of the other actions that already have Binding, by ActionMap.ActionsBoundTo.
+	// Remove Binding from them by ActionMap.UnbindOverlapping.
+	Conflicts []string
+}
+
+// BindingCapture listens for the next input to bind it to an action of an ActionMap, such as in an options menu.
+//
+// Inputs held when the capture started are ignored until they are released,
+// so the key that opened the capture is not captured.
+// Cancel keys finish the capture without a binding. The default cancel key is ebiten.KeyEscape.
+//
+// BindingCapture does not change the ActionMap. Bind the captured input by the result of Update.
+type BindingCapture struct {
+	actions         *ActionMap
+	action          string
+	capturing       bool
+	cancelKeys      []ebiten.Key
+	axisThreshold   float64
+	rejectConflicts bool
+
+	held map[Binding]struct{}
+
+	tmpKeys            []ebiten.Key
+	tmpButtons         []ebiten.GamepadButton
+	tmpStandardButtons []ebiten.StandardGamepadButton
+	tmpBindings        []Binding
+}
+
+// NewBindingCapture returns a BindingCapture that reads input through actions and checks conflicts with its actions.
+func NewBindingCapture(actions *ActionMap) *BindingCapture {
+	return &BindingCapture{
+		actions:       actions,
+		cancelKeys:    []ebiten.Key{ebiten.KeyEscape},
+		axisThreshold: 0.5,
+		held:          make(map[Binding]struct{}),
+	}
+}
+
+// SetCancelKeys sets the keys that cancel the capture. With no keys, the capture can be canceled only by Cancel.
+func (c *BindingCapture) SetCancelKeys(keys ...ebiten.Key) {
+	c.cancelKeys = slices.Clone(keys)
+}
+
+// SetAxisThreshold sets how far an axis must be moved to be captured, and the threshold of the captured binding.
+// The default threshold is 0.5. The sign of threshold is ignored, and thresholds greater than 1 are treated as 1.
+// A threshold of 0 is ignored, since it would capture any small movement of a stick.
+func (c *BindingCapture) SetAxisThreshold(threshold float64) {
+	t := abs(threshold)
+	if !(t > 0) {
+		return
+	}
+	c.axisThreshold = min(t, 1)
+}
+
+// SetRejectConflicts sets whether inputs bound to other actions are rejected.
+// If false, which is the default, such inputs are captured and the conflicts are reported in the result.
+func (c *BindingCapture) SetRejectConflicts(reject bool) {
+	c.rejectConflicts = reject
+}
+
+// Start starts capturing an input for the action.
+func (c *BindingCapture) Start(action string) {
+	c.action = action
+	c.capturing = true
+	clear(c.held)
+	for _, b := range c.appendActive(c.tmpBindings[:0]) {
+		c.held[b] = struct{}{}
+	}
+}
+
+// Cancel stops capturing without a binding.
+func (c *BindingCapture) Cancel() {
+	c.capturing = false
+}
+
+// IsCapturing reports whether the capture is in progress.
+func (c *BindingCapture) IsCapturing() bool {
+	return c.capturing
+}
+
+// Action returns the action of the current or the last capture.
+func (c *BindingCapture) Action() string {
+	return c.action
+}
+
+// Update watches the input of the current tick. Call Update once per tick after ActionMap.Update while capturing.
+func (c *BindingCapture) Update() CaptureResult {
+	if !c.capturing {
+		return CaptureResult{}
+	}
+
+	// Forget held inputs once they are released.
+	active := c.appendActive(c.tmpBindings[:0])
+	c.tmpBindings = active
+	for b := range c.held {
+		if !slices.Contains(active, b) {
+			delete(c.held, b)
+		}
+	}
+
+	if c.isCancelKeyJustPressed() {
+		c.capturing = false
+		return CaptureResult{Status: CaptureStatusCanceled}
+	}
+
+	b, ok := c.next()
+	if !ok {
+		return CaptureResult{}
+	}
+
+	var conflicts []string
+	for _, name := range c.actions.ActionsBoundTo(b) {
+		if name != c.action {
+			conflicts = append(conflicts, name)
+		}
+	}
+	if len(conflicts) > 0 && c.rejectConflicts {
+		c.held[b] = struct{}{}
+		return CaptureResult{Status: CaptureStatusRejected, Binding: b, Conflicts: conflicts}
+	}
+
+	c.capturing = false
+	return CaptureResult{Status: CaptureStatusCaptured, Binding: b, Conflicts: conflicts}
+}
+
+func (c *BindingCapture) isHeld(b Binding) bool {
+	_, ok := c.held[b]
+	return ok
+}
+
+func (c *BindingCapture) isCancelKeyJustPressed() bool {
+	r := &c.actions.reader
+	if r.keyboard == nil {
+		return false
+	}
+	for _, k := range c.cancelKeys {
+		if r.keyboard.IsJustPressed(k) && !c.isHeld(KeyBinding(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// next returns the first just pressed input that is not held.
+func (c *BindingCapture) next() (Binding, bool) {
+	r := &c.actions.reader
+
+	if r.keyboard != nil {
+		c.tmpKeys = r.keyboard.AppendJustPressed(c.tmpKeys[:0])
+		for _, k := range c.tmpKeys {
+			if b := KeyBinding(k); !c.isHeld(b) {
+				return b, true
+			}
+		}
+	}
+
+	if r.mouse != nil {
+		for mb := ebiten.MouseButton(0); mb <= ebiten.MouseButtonMax; mb++ {
+			if b := MouseButtonBinding(mb); r.mouse.IsJustPressed(mb) && !c.isHeld(b) {
+				return b, true
+			}
+		}
+	}
+
+	if r.gamepad == nil {
+		return Binding{}, false
+	}
+	for _, id := range r.ids() {
+		if r.gamepad.IsStandardLayoutAvailable(id) {
+			c.tmpStandardButtons = r.gamepad.AppendJustPressedStandardButtons(id, c.tmpStandardButtons[:0])
+			for _, sb := range c.tmpStandardButtons {
+				if b := StandardGamepadButtonBinding(sb); !c.isHeld(b) {
+					return b, true
+				}
+			}
+		} else {
+			c.tmpButtons = r.gamepad.AppendJustPressedButtons(id, c.tmpButtons[:0])
+			for _, gb := range c.tmpButtons {
+				if b := GamepadButtonBinding(gb); !c.isHeld(b) {
+					return b, true
+				}
+			}
+		}
+	}
+
+	// Axes have no just-pressed state, so any moved axis that is not held is taken as new.
+	for _, b := range c.tmpBindings {
+		if b.IsAxis() && !c.isHeld(b) {
+			return b, true
+		}
+	}
+
+	return Binding{}, false
+}
+
+// appendActive appends the bindings of all the inputs pressed or moved beyond the axis threshold at the current tick.
+func (c *BindingCapture) appendActive(bindings []Binding) []Binding {
+	r := &c.actions.reader
+
+	if r.keyboard != nil {
+		c.tmpKeys = r.keyboard.AppendPressed(c.tmpKeys[:0])
+		for _, k := range c.tmpKeys {
+			bindings = append(bindings, KeyBinding(k))
+		}
+	}
+
+	if r.mouse != nil {
+		for mb := ebiten.MouseButton(0); mb <= ebiten.MouseButtonMax; mb++ {
+			if r.mouse.IsPressed(mb) {
+				bindings = append(bindings, MouseButtonBinding(mb))
+			}
+		}
+	}
+
+	if r.gamepad == nil {
+		return bindings
+	}
+	for _, id := range r.ids() {
+		if r.gamepad.IsStandardLayoutAvailable(id) {
+			c.tmpStandardButtons = r.gamepad.AppendPressedStandardButtons(id, c.tmpStandardButtons[:0])
+			for _, sb := range c.tmpStandardButtons {
+				bindings = append(bindings, StandardGamepadButtonBinding(sb))
+			}
+			for a := ebiten.StandardGamepadAxis(0); a <= ebiten.StandardGamepadAxisMax; a++ {
+				if t, ok := c.axisDirection(r.gamepad.StandardAxisValue(id, a)); ok {
+					bindings = append(bindings, StandardGamepadAxisBinding(a, t))
+				}
+			}
+		} else {
+			c.tmpButtons = r.gamepad.AppendPressedButtons(id, c.tmpButtons[:0])
+			for _, gb := range c.tmpButtons {
+				bindings = append(bindings, GamepadButtonBinding(gb))
+			}
+			for a := range r.gamepad.AxisCount(id) {
+				if t, ok := c.axisDirection(r.gamepad.AxisValue(id, a)); ok {
+					bindings = append(bindings, GamepadAxisBinding(a, t))
+				}
+			}
+		}
+	}
+	return bindings
+}
+
+// axisDirection returns the signed threshold for an axis value beyond the axis threshold.
+func (c *BindingCapture) axisDirection(value float64) (float64, bool) {
+	switch {
+	case value >= c.axisThreshold && value > 0:
+		return c.axisThreshold, true
+	case value <= -c.axisThreshold && value < 0:
+		return -c.axisThreshold, true
+	}
+	return 0, false
+}
@@ -0,0 +1,135 @@
+package nyuuryoku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// The bindings document
+//
+// Bindings of an ActionMap are saved as a JSON document with the names of Binding.String:
+//
+//	{
+//	  "version": 2,
+//	  "actions": {
+//	    "jump": ["KeySpace", "StandardGamepadButtonRightBottom"],
+//	    "left": ["KeyArrowLeft", "axis:StandardGamepadAxisLeftStickHorizontal:-0.5"]
+//	  }
+//	}
+//
+// The version is the version of the game's actions, not of nyuuryoku.
+// Increase it when actions are renamed or removed, and add a BindingsMigration for the old version.
+
+// BindingsMigration converts the actions of a bindings document of version From into version From+1.
+// Migrate can rename, remove and add actions and change names of inputs in actions.
+// A nil Migrate changes nothing, such as for a version that changed only the format of other data.
+type BindingsMigration struct {
+	From    int
+	Migrate func(actions map[string][]string) error
+}
+
+// BindingsError is an invalid entry in a bindings document.
+// Index is the index of the binding in the action.
+type BindingsError struct {
+	Action string
+	Index  int
+	Msg    string
+}
+
+func (e *BindingsError) Error() string {
+	return fmt.Sprintf("nyuuryoku: bindings: actions[%q][%d]: %s", e.Action, e.Index, e.Msg)
+}
+
+type bindingsDocument[T any] struct {
+	Version *int           `json:"version"`
+	Actions map[string][]T `json:"actions"`
+}
+
+// WriteBindings writes the bindings of all the actions of m to w as a bindings document of the version.
+func WriteBindings(w io.Writer, m *ActionMap, version int) error {
+	doc := bindingsDocument[Binding]{
+		Version: &version,
+		Actions: make(map[string][]Binding, len(m.names)),
+	}
+	for _, name := range m.names {
+		bindings := m.actions[name].bindings
+		if bindings == nil {
+			bindings = []Binding{}
+		}
+		doc.Actions[name] = bindings
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(doc); err != nil {
+		return fmt.Errorf("nyuuryoku: writing bindings: %w", err)
+	}
+	return nil
+}
+
+// ReadBindings reads a bindings document from r and replaces the bindings of its actions in m.
+//
+// A document older than the version is migrated by migrations one version at a time.
+// Actions not in the document keep their bindings in m, so actions added after the document was saved keep their defaults.
+// Actions in the document that m does not have, such as actions removed from the game, are skipped
+// and returned in sorted order, so a stale action does not discard the other bindings of the user.
+//
+// The document is validated as a whole before m is changed. An invalid entry is reported as a *BindingsError.
+func ReadBindings(r io.Reader, m *ActionMap, version int, migrations ...BindingsMigration) (skipped []string, err error) {
+	var doc bindingsDocument[string]
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("nyuuryoku: reading bindings: %w", err)
+	}
+	if doc.Version == nil {
+		return nil, errors.New("nyuuryoku: bindings: missing version")
+	}
+	if doc.Actions == nil {
+		doc.Actions = map[string][]string{}
+	}
+
+	v := *doc.Version
+	if v > version {
+		return nil, fmt.Errorf("nyuuryoku: bindings: version %d is newer than %d", v, version)
+	}
+	for ; v < version; v++ {
+		i := slices.IndexFunc(migrations, func(m BindingsMigration) bool {
+			return m.From == v
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("nyuuryoku: bindings: no migration from version %d", v)
+		}
+		if migrations[i].Migrate == nil {
+			continue
+		}
+		if err := migrations[i].Migrate(doc.Actions); err != nil {
+			return nil, fmt.Errorf("nyuuryoku: bindings: migrating from version %d: %w", v, err)
+		}
+	}
+
+	names := slices.Sorted(maps.Keys(doc.Actions))
+	actions := make(map[string][]Binding, len(names))
+	for _, name := range names {
+		if _, ok := m.actions[name]; !ok {
+			skipped = append(skipped, name)
+			continue
+		}
+		bindings := make([]Binding, 0, len(doc.Actions[name]))
+		for i, s := range doc.Actions[name] {
+			b, err := parseBinding(s)
+			if err != nil {
+				return nil, &BindingsError{Action: name, Index: i, Msg: err.Error()}
+			}
+			bindings = append(bindings, b)
+		}
+		actions[name] = bindings
+	}
+
+	for name, bindings := range actions {
+		m.SetBindings(name, bindings)
+	}
+	return skipped, nil
+}
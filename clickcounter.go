@@ -0,0 +1,89 @@
+package nyuuryoku
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type clickState struct {
+	count     int
+	lastTick  int
+	lastX     int
+	lastY     int
+	justCount int
+}
+
+// ClickCounter counts multiple clicks of each mouse button, such as double-clicks and triple-clicks.
+//
+// A press continues the clicks of the previous press when it comes within the max interval in ticks
+// and within the max distance in pixels from the previous press. Otherwise, it starts a new count from 1.
+//
+// Call Update once per tick.
+type ClickCounter struct {
+	mouse       *Mouse
+	maxInterval int
+	maxDistance float64
+
+	tick   int
+	states [ebiten.MouseButtonMax + 1]clickState
+}
+
+// NewClickCounter returns a ClickCounter with a max interval of 30 ticks and a max distance of 4 pixels.
+func NewClickCounter(mouse *Mouse) *ClickCounter {
+	return &ClickCounter{
+		mouse:       mouse,
+		maxInterval: 30,
+		maxDistance: 4,
+	}
+}
+
+// SetMaxInterval sets the longest interval in ticks between two presses of the same clicks.
+func (c *ClickCounter) SetMaxInterval(ticks int) {
+	c.maxInterval = ticks
+}
+
+// SetMaxDistance sets the longest distance in pixels between two presses of the same clicks.
+func (c *ClickCounter) SetMaxDistance(pixels float64) {
+	c.maxDistance = pixels
+}
+
+// Update reads the mouse at the current tick and counts the presses.
+func (c *ClickCounter) Update() {
+	c.tick++
+	x, y := c.mouse.CursorPosition()
+
+	for b := range c.states {
+		s := &c.states[b]
+		s.justCount = 0
+		if !c.mouse.IsJustPressed(ebiten.MouseButton(b)) {
+			continue
+		}
+
+		near := math.Hypot(float64(x-s.lastX), float64(y-s.lastY)) <= c.maxDistance
+		if s.count > 0 && c.tick-s.lastTick <= c.maxInterval && near {
+			s.count++
+		} else {
+			s.count = 1
+		}
+		s.lastTick = c.tick
+		s.lastX, s.lastY = x, y
+		s.justCount = s.count
+	}
+}
+
+// ClickCount returns the number of clicks of the press of the button at the current tick,
+// such as 2 for a double-click, or 0 if the button is not just pressed.
+func (c *ClickCounter) ClickCount(button ebiten.MouseButton) int {
+	if !isValidMouseButton(button) {
+		return 0
+	}
+	return c.states[button].justCount
+}
+
+// Reset starts new counts for all the buttons, such as after the content under the cursor changes.
+func (c *ClickCounter) Reset() {
+	for b := range c.states {
+		c.states[b].count = 0
+	}
+}
@@ -0,0 +1,197 @@
+package nyuuryoku
+
+import (
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// AxisCombine is how a composite axis combines the values of its sources.
+type AxisCombine int
+
+const (
+	// AxisCombineMaxMagnitude uses the source with the largest magnitude.
+	AxisCombineMaxMagnitude AxisCombine = iota
+	// AxisCombineSumClamped adds all the sources and clamps the magnitude to 1.
+	AxisCombineSumClamped
+	// AxisCombineLastUsed uses the source that started moving most recently among the moving sources.
+	AxisCombineLastUsed
+)
+
+// AxisBinding is a source of a 1D composite axis, whose value is the value of Positive minus the value of Negative.
+type AxisBinding struct {
+	Negative Binding
+	Positive Binding
+}
+
+// ButtonAxis returns an AxisBinding of a pair of buttons. Axis bindings can also be used as buttons.
+func ButtonAxis(negative, positive Binding) AxisBinding {
+	return AxisBinding{Negative: negative, Positive: positive}
+}
+
+// StandardGamepadAxisSource returns an AxisBinding of an analog standard axis.
+func StandardGamepadAxisSource(axis ebiten.StandardGamepadAxis) AxisBinding {
+	return AxisBinding{
+		Negative: StandardGamepadAxisBinding(axis, -1),
+		Positive: StandardGamepadAxisBinding(axis, 1),
+	}
+}
+
+// GamepadAxisSource returns an AxisBinding of an analog axis.
+func GamepadAxisSource(axis int) AxisBinding {
+	return AxisBinding{
+		Negative: GamepadAxisBinding(axis, -1),
+		Positive: GamepadAxisBinding(axis, 1),
+	}
+}
+
+// VectorBinding is a source of a 2D composite axis.
+// X is the value of Right minus the value of Left, and Y is the value of Down minus the value of Up,
+// in the same directions as screen coordinates and gamepad sticks.
+type VectorBinding struct {
+	Up    Binding
+	Down  Binding
+	Left  Binding
+	Right Binding
+}
+
+// ButtonsVector returns a VectorBinding of four buttons.
+func ButtonsVector(up, down, left, right Binding) VectorBinding {
+	return VectorBinding{Up: up, Down: down, Left: left, Right: right}
+}
+
+// StandardDPadVector returns a VectorBinding of the d-pad of the standard layout.
+func StandardDPadVector() VectorBinding {
+	return ButtonsVector(
+		StandardGamepadButtonBinding(ebiten.StandardGamepadButtonLeftTop),
+		StandardGamepadButtonBinding(ebiten.StandardGamepadButtonLeftBottom),
+		StandardGamepadButtonBinding(ebiten.StandardGamepadButtonLeftLeft),
+		StandardGamepadButtonBinding(ebiten.StandardGamepadButtonLeftRight),
+	)
+}
+
+// StandardGamepadStickVector returns a VectorBinding of a stick of the standard layout.
+func StandardGamepadStickVector(horizontal, vertical ebiten.StandardGamepadAxis) VectorBinding {
+	h := StandardGamepadAxisSource(horizontal)
+	v := StandardGamepadAxisSource(vertical)
+	return VectorBinding{Up: v.Negative, Down: v.Positive, Left: h.Negative, Right: h.Positive}
+}
+
+// GamepadStickVector returns a VectorBinding of a stick made of two analog axes.
+func GamepadStickVector(horizontal, vertical int) VectorBinding {
+	h := GamepadAxisSource(horizontal)
+	v := GamepadAxisSource(vertical)
+	return VectorBinding{Up: v.Negative, Down: v.Positive, Left: h.Negative, Right: h.Positive}
+}
+
+type compositeState struct {
+	sources []VectorBinding
+	combine AxisCombine
+	curve   ResponseCurve
+
+	// activeSince is the tick when each source started moving, or 0 if it is not moving.
+	activeSince []int
+
+	x, y float64
+}
+
+func (m *ActionMap) composite(name string) *compositeState {
+	c, ok := m.composites[name]
+	if !ok {
+		c = &compositeState{}
+		m.composites[name] = c
+		m.compositeNames = append(m.compositeNames, name)
+	}
+	return c
+}
+
+// BindAxis adds sources to the 1D composite axis. The value is read by Axis.
+func (m *ActionMap) BindAxis(axis string, sources ...AxisBinding) {
+	for _, s := range sources {
+		m.BindVector(axis, VectorBinding{Left: s.Negative, Right: s.Positive})
+	}
+}
+
+// BindVector adds sources to the 2D composite axis. The value is read by Vector.
+func (m *ActionMap) BindVector(vector string, sources ...VectorBinding) {
+	c := m.composite(vector)
+	for _, s := range sources {
+		if !slices.Contains(c.sources, s) {
+			c.sources = append(c.sources, s)
+			c.activeSince = append(c.activeSince, 0)
+		}
+	}
+}
+
+// SetAxisCombine sets how the 1D or 2D composite axis combines its sources. The default is AxisCombineMaxMagnitude.
+func (m *ActionMap) SetAxisCombine(axis string, combine AxisCombine) {
+	m.composite(axis).combine = combine
+}
+
+// SetAxisCurve sets the response curve applied to the magnitude of the 1D or 2D composite axis.
+func (m *ActionMap) SetAxisCurve(axis string, curve ResponseCurve) {
+	m.composite(axis).curve = curve
+}
+
+// Axis returns the value of the 1D composite axis in the range [-1, 1].
+func (m *ActionMap) Axis(axis string) float64 {
+	c, ok := m.composites[axis]
+	if !ok {
+		return 0
+	}
+	return c.x
+}
+
+// Vector returns the value of the 2D composite axis. The length of the vector is at most 1,
+// so diagonals made of two buttons are as long as straight directions.
+func (m *ActionMap) Vector(vector string) (x, y float64) {
+	c, ok := m.composites[vector]
+	if !ok {
+		return 0, 0
+	}
+	return c.x, c.y
+}
+
+func (m *ActionMap) updateComposite(c *compositeState) {
+	var x, y float64
+	var best float64
+	bestSince := 0
+	for i, s := range c.sources {
+		sx := m.reader.value(s.Right) - m.reader.value(s.Left)
+		sy := m.reader.value(s.Down) - m.reader.value(s.Up)
+		sx, sy = clampLength(sx, sy)
+
+		l := math.Hypot(sx, sy)
+		switch {
+		case l == 0:
+			c.activeSince[i] = 0
+		case c.activeSince[i] == 0:
+			c.activeSince[i] = m.tick
+		}
+
+		switch c.combine {
+		case AxisCombineMaxMagnitude:
+			if l > best {
+				x, y, best = sx, sy, l
+			}
+		case AxisCombineSumClamped:
+			x += sx
+			y += sy
+		case AxisCombineLastUsed:
+			if l > 0 && c.activeSince[i] >= bestSince {
+				x, y, bestSince = sx, sy, c.activeSince[i]
+			}
+		}
+	}
+	c.x, c.y = c.curve.applyLength(clampLength(x, y))
+}
+
+// clampLength scales (x, y) down to length 1 if it is longer.
+func clampLength(x, y float64) (float64, float64) {
+	l := math.Hypot(x, y)
+	if l <= 1 {
+		return x, y
+	}
+	return x / l, y / l
+}
@@ -0,0 +1,102 @@
+package nyuuryoku
+
+import (
+	"image"
+)
+
+// CursorTracker samples the cursor position of a Mouse at each tick,
+// and reports the movement since the previous tick, the velocity and the recent positions.
+//
+// When the functions of the Mouse are set by MouseSetter, such as by VirtualMouse.Attach or MouseSetter.SetDefault,
+// the tracker starts over from the position of the new source instead of reporting the jump as a movement.
+// Call Reset when the cursor jumps for another reason.
+//
+// Call Update once per tick.
+type CursorTracker struct {
+	mouse      *Mouse
+	generation int
+
+	// positions is a ring buffer of the recent positions. start is the index of the oldest one.
+	positions []image.Point
+	start     int
+	count     int
+
+	deltaX, deltaY int
+}
+
+// NewCursorTracker returns a CursorTracker that keeps the positions of the last samples ticks.
+// samples less than 2 are treated as 2.
+func NewCursorTracker(mouse *Mouse, samples int) *CursorTracker {
+	return &CursorTracker{
+		mouse:     mouse,
+		positions: make([]image.Point, max(samples, 2)),
+	}
+}
+
+// Reset forgets the recent positions. The next Update reports no movement.
+func (t *CursorTracker) Reset() {
+	t.start = 0
+	t.count = 0
+	t.deltaX, t.deltaY = 0, 0
+}
+
+// Update samples the cursor position at the current tick.
+func (t *CursorTracker) Update() {
+	if g := t.mouse.Generation(); g != t.generation {
+		t.generation = g
+		t.Reset()
+	}
+
+	x, y := t.mouse.CursorPosition()
+	p := image.Pt(x, y)
+
+	if t.count > 0 {
+		last := t.at(t.count - 1)
+		t.deltaX, t.deltaY = p.X-last.X, p.Y-last.Y
+	}
+	if t.count < len(t.positions) {
+		t.positions[(t.start+t.count)%len(t.positions)] = p
+		t.count++
+		return
+	}
+	t.positions[t.start] = p
+	t.start = (t.start + 1) % len(t.positions)
+}
+
+// at returns the i-th oldest position.
+func (t *CursorTracker) at(i int) image.Point {
+	return t.positions[(t.start+i)%len(t.positions)]
+}
+
+// Position returns the cursor position at the last Update.
+func (t *CursorTracker) Position() (x, y int) {
+	if t.count == 0 {
+		return 0, 0
+	}
+	p := t.at(t.count - 1)
+	return p.X, p.Y
+}
+
+// Delta returns the cursor movement since the previous tick.
+func (t *CursorTracker) Delta() (dx, dy int) {
+	return t.deltaX, t.deltaY
+}
+
+// Velocity returns the average velocity in pixels per tick over the recent positions.
+// It is 0 until two positions are sampled.
+func (t *CursorTracker) Velocity() (vx, vy float64) {
+	if t.count < 2 {
+		return 0, 0
+	}
+	d := t.at(t.count - 1).Sub(t.at(0))
+	ticks := float64(t.count - 1)
+	return float64(d.X) / ticks, float64(d.Y) / ticks
+}
+
+// AppendPositions appends the recent positions to dst from the oldest one, and returns the extended slice.
+func (t *CursorTracker) AppendPositions(dst []image.Point) []image.Point {
+	for i := range t.count {
+		dst = append(dst, t.at(i))
+	}
+	return dst
+}
@@ -0,0 +1,162 @@
+package nyuuryoku
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// DeadzoneKind is the shape of the inner deadzone of a stick.
+type DeadzoneKind int
+
+const (
+	// DeadzoneNone applies no deadzones, neither inner nor outer.
+	DeadzoneNone DeadzoneKind = iota
+	// DeadzoneAxial zeroes each axis whose magnitude is within the inner deadzone.
+	// It snaps to the cardinal directions, but has a cross-shaped dead area.
+	DeadzoneAxial
+	// DeadzoneRadial zeroes the stick when its magnitude is within the inner deadzone.
+	// The output jumps from 0 to the inner size at the edge of the deadzone.
+	DeadzoneRadial
+	// DeadzoneScaledRadial is DeadzoneRadial whose magnitude is rescaled to start from 0 at the edge of the deadzone.
+	DeadzoneScaledRadial
+	// DeadzoneHybrid is DeadzoneScaledRadial that also zeroes each axis whose raw magnitude is within the inner deadzone,
+	// so movement close to a cardinal direction snaps to it.
+	DeadzoneHybrid
+)
+
+// StickDeadzone is the deadzones of a stick made of a horizontal and a vertical axis.
+//
+// Inner is the size of the inner deadzone in the range [0, 1].
+// Outer is the size of the outer deadzone in the range [0, 1]: magnitudes greater than or equal to 1-Outer are treated as 1.
+// The outer deadzone applies to each axis with DeadzoneAxial, and to the magnitude with DeadzoneRadial,
+// DeadzoneScaledRadial and DeadzoneHybrid. DeadzoneNone ignores both Inner and Outer.
+type StickDeadzone struct {
+	Kind  DeadzoneKind
+	Inner float64
+	Outer float64
+}
+
+// Apply returns the stick position (x, y) with the deadzones applied.
+// The length of the result is at most 1 except for DeadzoneNone and DeadzoneAxial, whose axes are at most 1 each.
+func (d StickDeadzone) Apply(x, y float64) (float64, float64) {
+	outer := 1 - d.Outer
+	switch d.Kind {
+	case DeadzoneAxial:
+		return d.axial(x, outer), d.axial(y, outer)
+	case DeadzoneRadial, DeadzoneScaledRadial, DeadzoneHybrid:
+		l := math.Hypot(x, y)
+		if l == 0 || l < d.Inner {
+			return 0, 0
+		}
+		nl := min(l, 1)
+		if l >= outer {
+			nl = 1
+		} else if d.Kind != DeadzoneRadial {
+			nl = rescale(l, d.Inner, outer)
+		}
+		nx, ny := x/l*nl, y/l*nl
+		if d.Kind == DeadzoneHybrid {
+			if math.Abs(x) < d.Inner {
+				nx = 0
+			}
+			if math.Abs(y) < d.Inner {
+				ny = 0
+			}
+		}
+		return nx, ny
+	}
+	return x, y
+}
+
+func (d StickDeadzone) axial(v, outer float64) float64 {
+	a := math.Abs(v)
+	switch {
+	case a < d.Inner:
+		return 0
+	case a >= outer:
+		return math.Copysign(1, v)
+	}
+	return v
+}
+
+// rescale maps v in [from, to] to [0, 1].
+func rescale(v, from, to float64) float64 {
+	if to <= from {
+		return 1
+	}
+	return max(0, min(1, (v-from)/(to-from)))
+}
+
+// StickProcessor applies deadzones and response curves to the standard axes of gamepad sticks
+// as pairs of a horizontal and a vertical axis.
+//
+// Install it with GamepadSetter.SetStandardAxisValueFunc and Wrap:
+//
+//	p := nyuuryoku.NewStickProcessor()
+//	p.SetDeadzone(ebiten.StandardGamepadAxisLeftStickHorizontal, ebiten.StandardGamepadAxisLeftStickVertical, d)
+//	setter.SetStandardAxisValueFunc(p.Wrap(ebiten.StandardGamepadAxisValue))
+type StickProcessor struct {
+	sticks []stickSettings
+}
+
+type stickSettings struct {
+	horizontal ebiten.StandardGamepadAxis
+	vertical   ebiten.StandardGamepadAxis
+	deadzone   StickDeadzone
+	curve      ResponseCurve
+}
+
+// NewStickProcessor returns a StickProcessor with the left and right sticks of the standard layout, no deadzones and linear curves.
+func NewStickProcessor() *StickProcessor {
+	return &StickProcessor{
+		sticks: []stickSettings{
+			{horizontal: ebiten.StandardGamepadAxisLeftStickHorizontal, vertical: ebiten.StandardGamepadAxisLeftStickVertical},
+			{horizontal: ebiten.StandardGamepadAxisRightStickHorizontal, vertical: ebiten.StandardGamepadAxisRightStickVertical},
+		},
+	}
+}
+
+func (p *StickProcessor) stick(horizontal, vertical ebiten.StandardGamepadAxis) *stickSettings {
+	for i := range p.sticks {
+		if p.sticks[i].horizontal == horizontal && p.sticks[i].vertical == vertical {
+			return &p.sticks[i]
+		}
+	}
+	p.sticks = append(p.sticks, stickSettings{horizontal: horizontal, vertical: vertical})
+	return &p.sticks[len(p.sticks)-1]
+}
+
+// SetDeadzone sets the deadzones of the stick made of the two axes.
+func (p *StickProcessor) SetDeadzone(horizontal, vertical ebiten.StandardGamepadAxis, deadzone StickDeadzone) {
+	p.stick(horizontal, vertical).deadzone = deadzone
+}
+
+// SetCurve sets the response curve of the stick made of the two axes.
+// The curve is applied to the magnitude of the stick after the deadzones.
+func (p *StickProcessor) SetCurve(horizontal, vertical ebiten.StandardGamepadAxis, curve ResponseCurve) {
+	p.stick(horizontal, vertical).curve = curve
+}
+
+// Wrap returns a function that returns the values of source processed by p.
+// Axes that are not part of a stick of p are returned as they are.
+func (p *StickProcessor) Wrap(source func(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64) func(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64 {
+	return func(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64 {
+		return p.standardAxisValue(source, id, axis)
+	}
+}
+
+func (p *StickProcessor) standardAxisValue(source func(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64, id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64 {
+	for _, s := range p.sticks {
+		if axis != s.horizontal && axis != s.vertical {
+			continue
+		}
+		x, y := s.deadzone.Apply(source(id, s.horizontal), source(id, s.vertical))
+		x, y = s.curve.applyLength(x, y)
+		if axis == s.horizontal {
+			return x
+		}
+		return y
+	}
+	return source(id, axis)
+}
@@ -0,0 +1,179 @@
+package nyuuryoku
+
+import (
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// DragPhase is what happened to a drag at a tick.
+type DragPhase int
+
+const (
+	// DragPhaseNone means that nothing happened, including while a drag continues without moving.
+	DragPhaseNone DragPhase = iota
+	// DragPhaseStarted means that the cursor moved beyond the threshold while the button was held.
+	DragPhaseStarted
+	// DragPhaseMoved means that the cursor moved during a drag.
+	DragPhaseMoved
+	// DragPhaseEnded means that the button was released during a drag.
+	DragPhaseEnded
+	// DragPhaseCanceled means that a cancel key was pressed during a drag.
+	// The drag does not restart until the button is released.
+	DragPhaseCanceled
+)
+
+// Drag is the state of a drag of a mouse button.
+type Drag struct {
+	Phase DragPhase
+
+	// StartX and StartY are the cursor position where the button was pressed.
+	StartX, StartY int
+
+	// X and Y are the current cursor position.
+	X, Y int
+
+	// DeltaX and DeltaY are the cursor movement since the previous tick.
+	DeltaX, DeltaY int
+}
+
+// Total returns the cursor movement from the start of the drag.
+func (d Drag) Total() (dx, dy int) {
+	return d.X - d.StartX, d.Y - d.StartY
+}
+
+type dragState struct {
+	drag Drag
+
+	// held is whether the button has been held since a press seen by the tracker.
+	held     bool
+	dragging bool
+	canceled bool
+}
+
+// DragTracker tracks drags of each mouse button independently.
+// A drag starts only after the cursor moves beyond the threshold from where the button was pressed,
+// so clicks with small movement are not drags.
+//
+// Call Update once per tick.
+type DragTracker struct {
+	mouse      *Mouse
+	keyboard   *Keyboard
+	threshold  float64
+	cancelKeys []ebiten.Key
+
+	states [ebiten.MouseButtonMax + 1]dragState
+}
+
+// NewDragTracker returns a DragTracker with a threshold of 4 pixels.
+// Drags are canceled by ebiten.KeyEscape on keyboard. keyboard may be nil, in which case drags are never canceled by keys.
+func NewDragTracker(mouse *Mouse, keyboard *Keyboard) *DragTracker {
+	return &DragTracker{
+		mouse:      mouse,
+		keyboard:   keyboard,
+		threshold:  4,
+		cancelKeys: []ebiten.Key{ebiten.KeyEscape},
+	}
+}
+
+// SetThreshold sets how far in pixels the cursor must move from where the button was pressed to start a drag.
+func (t *DragTracker) SetThreshold(pixels float64) {
+	t.threshold = pixels
+}
+
+// SetCancelKeys sets the keys that cancel drags. With no keys, drags are never canceled by keys.
+func (t *DragTracker) SetCancelKeys(keys ...ebiten.Key) {
+	t.cancelKeys = slices.Clone(keys)
+}
+
+// Cancel cancels the drag of the button. The drag does not restart until the button is released.
+func (t *DragTracker) Cancel(button ebiten.MouseButton) {
+	if !isValidMouseButton(button) {
+		return
+	}
+	s := &t.states[button]
+	if s.held {
+		s.dragging = false
+		s.canceled = true
+	}
+}
+
+// Update reads the mouse at the current tick and advances the drags of all the buttons.
+func (t *DragTracker) Update() {
+	x, y := t.mouse.CursorPosition()
+	cancel := t.isCancelKeyJustPressed()
+
+	for b := range t.states {
+		s := &t.states[b]
+		button := ebiten.MouseButton(b)
+
+		d := &s.drag
+		d.Phase = DragPhaseNone
+		d.DeltaX, d.DeltaY = x-d.X, y-d.Y
+		d.X, d.Y = x, y
+
+		switch {
+		case t.mouse.IsJustPressed(button):
+			*s = dragState{
+				drag: Drag{StartX: x, StartY: y, X: x, Y: y},
+				held: true,
+			}
+
+		case !s.held:
+
+		case t.mouse.IsJustReleased(button) || !t.mouse.IsPressed(button):
+			if s.dragging {
+				d.Phase = DragPhaseEnded
+			}
+			s.held = false
+			s.dragging = false
+
+		case s.canceled:
+
+		case cancel:
+			if s.dragging {
+				d.Phase = DragPhaseCanceled
+			}
+			s.dragging = false
+			s.canceled = true
+
+		case !s.dragging:
+			dx, dy := d.Total()
+			if math.Hypot(float64(dx), float64(dy)) >= t.threshold {
+				s.dragging = true
+				d.Phase = DragPhaseStarted
+			}
+
+		case d.DeltaX != 0 || d.DeltaY != 0:
+			d.Phase = DragPhaseMoved
+		}
+	}
+}
+
+func (t *DragTracker) isCancelKeyJustPressed() bool {
+	if t.keyboard == nil {
+		return false
+	}
+	return slices.ContainsFunc(t.cancelKeys, t.keyboard.IsJustPressed)
+}
+
+// Drag returns the drag of the button at the current tick.
+func (t *DragTracker) Drag(button ebiten.MouseButton) Drag {
+	if !isValidMouseButton(button) {
+		return Drag{}
+	}
+	return t.states[button].drag
+}
+
+// IsDragging reports whether the button is being dragged, including the tick the drag started.
+func (t *DragTracker) IsDragging(button ebiten.MouseButton) bool {
+	if !isValidMouseButton(button) {
+		return false
+	}
+	return t.states[button].dragging
+}
+
+func isValidMouseButton(button ebiten.MouseButton) bool {
+	return button >= 0 && button <= ebiten.MouseButtonMax
+}
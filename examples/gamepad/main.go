@@ -59,6 +59,10 @@ func (g *Game) Update() error {
 		g.switchGamepad()
 	}
 
+	if g.gamepadIsVirtual {
+		g.virtualGamepad.Update()
+	}
+
 	// Get connected gamepad IDs
 	var ids []ebiten.GamepadID
 	ids = g.gamepad.AppendIDs(ids)
@@ -161,22 +165,7 @@ func (g *Game) switchGamepad() {
 		setter.SetDefault()
 		g.appendLog("Switched to real gamepad")
 	} else {
-		// Only set functions for the virtual gamepad ID
-		setter.SetAppendIDsFunc(g.virtualGamepad.appendIDs)
-		setter.SetAppendJustConnectedIDsFunc(g.virtualGamepad.appendJustConnectedIDs)
-		setter.SetIsJustDisconnectedFunc(g.virtualGamepad.isJustDisconnected)
-		setter.SetNameFunc(g.virtualGamepad.name)
-		setter.SetSDLIDFunc(g.virtualGamepad.sdlID)
-		setter.SetIsStandardLayoutAvailableFunc(g.virtualGamepad.isStandardLayoutAvailable)
-		setter.SetAxisCountFunc(g.virtualGamepad.axisCount)
-		setter.SetAxisValueFunc(g.virtualGamepad.axisValue)
-		setter.SetButtonCountFunc(g.virtualGamepad.buttonCount)
-		setter.SetIsButtonPressedFunc(g.virtualGamepad.isButtonPressed)
-		setter.SetIsButtonJustPressedFunc(g.virtualGamepad.isButtonJustPressed)
-		setter.SetIsButtonJustReleasedFunc(g.virtualGamepad.isButtonJustReleased)
-		setter.SetIsStandardButtonPressedFunc(g.virtualGamepad.isStandardButtonPressed)
-		setter.SetIsStandardButtonJustPressedFunc(g.virtualGamepad.isStandardButtonJustPressed)
-		setter.SetIsStandardButtonJustReleasedFunc(g.virtualGamepad.isStandardButtonJustReleased)
+		g.virtualGamepad.Attach(g.gamepad)
 
 		g.appendLog("Switched to virtual gamepad")
 	}
@@ -259,198 +248,67 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return screenWidth, screenHeight
 }
 
-// buttonState tracks the state of a gamepad button
-type buttonState struct {
-	StateChangedCount int
-	Pressed           bool
-}
-
-// virtualGamepad implements a simulated gamepad
+// virtualGamepad animates a gamepad connected to a virtual gamepad hub
 type virtualGamepad struct {
-	ID                   ebiten.GamepadID
-	frame                int
-	connected            bool
-	justConnected        bool
-	justDisconnected     bool
-	standardLayout       bool
-	buttonStates         map[ebiten.GamepadButton]buttonState
-	standardButtonStates map[ebiten.StandardGamepadButton]buttonState
-	axisValues           []float64
+	*nyuuryoku.VirtualGamepad
+	ID    ebiten.GamepadID
+	frame int
 }
 
 func newVirtualGamepad() *virtualGamepad {
 	vg := &virtualGamepad{
-		ID:                   42, // Virtual ID
-		connected:            true,
-		justConnected:        true,
-		standardLayout:       true,
-		buttonStates:         make(map[ebiten.GamepadButton]buttonState),
-		standardButtonStates: make(map[ebiten.StandardGamepadButton]buttonState),
-		axisValues:           make([]float64, 4), // Four axes
+		VirtualGamepad: nyuuryoku.NewVirtualGamepad(),
 	}
+	vg.ID = vg.Connect(nyuuryoku.GamepadProfile{
+		Name:           "Virtual Gamepad",
+		SDLID:          "VIRTUAL-SDLID-X123",
+		ButtonCount:    12,
+		AxisCount:      4,
+		StandardLayout: true,
+	})
 	return vg
 }
 
 func (v *virtualGamepad) Update() {
 	v.frame++
 
-	// Reset just connected/disconnected after first frame
-	if v.frame > 1 {
-		v.justConnected = false
-		v.justDisconnected = false
-	}
-
 	// Update axis values with some animation
-	for i := range v.axisValues {
+	for i := 0; i < 4; i++ {
 		period := 120 + i*30 // Different period for each axis
 		amplitude := 0.8
 		// Sinusoidal-like movement
+		var value float64
 		if i%2 == 0 {
-			v.axisValues[i] = amplitude * float64((v.frame/period)%2*2-1) * float64(v.frame%period) / float64(period)
+			value = amplitude * float64((v.frame/period)%2*2-1) * float64(v.frame%period) / float64(period)
 		} else {
-			v.axisValues[i] = amplitude * float64((v.frame/period)%2*2-1) * (1.0 - float64(v.frame%period)/float64(period))
+			value = amplitude * float64((v.frame/period)%2*2-1) * (1.0 - float64(v.frame%period)/float64(period))
 		}
+		v.SetAxisValue(v.ID, i, value)
 	}
 
 	// Update standard button states
 	for btn := ebiten.StandardGamepadButton(0); btn < ebiten.StandardGamepadButtonMax; btn++ {
-		state := v.standardButtonStates[btn]
-
 		// Different pattern for each button
 		cycleLength := 60 + int(btn)*10
-		newPressed := ((v.frame + int(btn)*15) % cycleLength) < cycleLength/3
-
-		if state.Pressed != newPressed {
-			state.Pressed = newPressed
-			state.StateChangedCount = v.frame
-			v.standardButtonStates[btn] = state
+		if ((v.frame + int(btn)*15) % cycleLength) < cycleLength/3 {
+			v.PressStandardButton(v.ID, btn)
+		} else {
+			v.ReleaseStandardButton(v.ID, btn)
 		}
 	}
 
 	// Update regular button states
 	for btn := ebiten.GamepadButton(0); btn < 12; btn++ {
-		state := v.buttonStates[btn]
-
 		// Different pattern for each button
 		cycleLength := 90 + int(btn)*12
-		newPressed := ((v.frame + int(btn)*20) % cycleLength) < cycleLength/4
-
-		if state.Pressed != newPressed {
-			state.Pressed = newPressed
-			state.StateChangedCount = v.frame
-			v.buttonStates[btn] = state
+		if ((v.frame + int(btn)*20) % cycleLength) < cycleLength/4 {
+			v.PressButton(v.ID, btn)
+		} else {
+			v.ReleaseButton(v.ID, btn)
 		}
 	}
-}
-
-// Implementation of gamepad functions
-func (v *virtualGamepad) appendIDs(ids []ebiten.GamepadID) []ebiten.GamepadID {
-	v.Update() // Update virtual state
-	if v.connected {
-		return append(ids, v.ID)
-	}
-	return ids
-}
-
-func (v *virtualGamepad) appendJustConnectedIDs(ids []ebiten.GamepadID) []ebiten.GamepadID {
-	if v.justConnected {
-		return append(ids, v.ID)
-	}
-	return ids
-}
-
-func (v *virtualGamepad) isJustDisconnected(id ebiten.GamepadID) bool {
-	return id == v.ID && v.justDisconnected
-}
-
-func (v *virtualGamepad) name(id ebiten.GamepadID) string {
-	if id == v.ID {
-		return "Virtual Gamepad"
-	}
-	return ""
-}
-
-func (v *virtualGamepad) sdlID(id ebiten.GamepadID) string {
-	if id == v.ID {
-		return "VIRTUAL-SDLID-X123"
-	}
-	return ""
-}
 
-func (v *virtualGamepad) isStandardLayoutAvailable(id ebiten.GamepadID) bool {
-	if id == v.ID {
-		return v.standardLayout
-	}
-	return false
-}
-
-func (v *virtualGamepad) axisCount(id ebiten.GamepadID) int {
-	if id == v.ID {
-		return len(v.axisValues)
-	}
-	return 0
-}
-
-func (v *virtualGamepad) axisValue(id ebiten.GamepadID, axis int) float64 {
-	if id == v.ID && axis >= 0 && axis < len(v.axisValues) {
-		return v.axisValues[axis]
-	}
-	return 0
-}
-
-func (v *virtualGamepad) buttonCount(id ebiten.GamepadID) int {
-	if id == v.ID {
-		return 12 // Fixed number of buttons
-	}
-	return 0
-}
-
-func (v *virtualGamepad) isButtonPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
-	if id == v.ID {
-		state, exists := v.buttonStates[button]
-		return exists && state.Pressed
-	}
-	return false
-}
-
-func (v *virtualGamepad) isButtonJustPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
-	if id == v.ID {
-		state, exists := v.buttonStates[button]
-		return exists && state.Pressed && state.StateChangedCount == v.frame
-	}
-	return false
-}
-
-func (v *virtualGamepad) isButtonJustReleased(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
-	if id == v.ID {
-		state, exists := v.buttonStates[button]
-		return exists && !state.Pressed && state.StateChangedCount == v.frame
-	}
-	return false
-}
-
-func (v *virtualGamepad) isStandardButtonPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
-	if id == v.ID {
-		state, exists := v.standardButtonStates[button]
-		return exists && state.Pressed
-	}
-	return false
-}
-
-func (v *virtualGamepad) isStandardButtonJustPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
-	if id == v.ID {
-		state, exists := v.standardButtonStates[button]
-		return exists && state.Pressed && state.StateChangedCount == v.frame
-	}
-	return false
-}
-
-func (v *virtualGamepad) isStandardButtonJustReleased(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
-	if id == v.ID {
-		state, exists := v.standardButtonStates[button]
-		return exists && !state.Pressed && state.StateChangedCount == v.frame
-	}
-	return false
+	v.Tick()
 }
 
 func main() {
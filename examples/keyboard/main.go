@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"image/color"
 	"log"
-	"slices"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
@@ -104,10 +103,7 @@ func (g *game) switchKeyboard() {
 	if g.keyboardIsVirtual {
 		setter.SetDefault()
 	} else {
-		setter.SetAppendPressedFunc(g.virtualKeyboard.appendPressed)
-		setter.SetAppendJustPressedFunc(g.virtualKeyboard.appendJustPressed)
-		setter.SetAppendJustReleasedFunc(g.virtualKeyboard.appendJustReleased)
-		setter.SetPressDurationFunc(g.virtualKeyboard.pressedDuration)
+		g.virtualKeyboard.Attach(g.keyboard)
 	}
 
 	g.keyboardIsVirtual = !g.keyboardIsVirtual
@@ -148,90 +144,29 @@ func (g *game) drawLog(screen *ebiten.Image) {
 	}
 }
 
-type keyState struct {
-	StateChangedCount int
-	Pressed           bool
-}
-
+// virtualKeyboard presses and releases keys by a fixed pattern.
 type virtualKeyboard struct {
-	tmpKeys []ebiten.Key
-	states  map[ebiten.Key]keyState
-	count   int
+	*nyuuryoku.VirtualKeyboard
+	count int
 }
 
 func newVirtualKeyboard() *virtualKeyboard {
 	return &virtualKeyboard{
-		states: make(map[ebiten.Key]keyState),
+		VirtualKeyboard: nyuuryoku.NewVirtualKeyboard(),
 	}
 }
 
 func (k *virtualKeyboard) Update() {
 	k.count++
 	for key := range ebiten.KeyMax {
-		state, ok := k.states[key]
-		if !ok {
-			state = keyState{}
-		}
-
-		newPressed := (((k.count + int(key)*30) / (100 + int(key))) % 4) == 1
-		if state.Pressed != newPressed {
-			state.Pressed = newPressed
-			state.StateChangedCount = k.count
-			k.states[key] = state
+		pressed := (((k.count + int(key)*30) / (100 + int(key))) % 4) == 1
+		if pressed {
+			k.Press(key)
+		} else {
+			k.Release(key)
 		}
 	}
-}
-
-func (k *virtualKeyboard) keys() []ebiten.Key {
-	k.tmpKeys = k.tmpKeys[:0]
-	for key := range k.states {
-		k.tmpKeys = append(k.tmpKeys, key)
-	}
-
-	slices.Sort(k.tmpKeys)
-
-	return k.tmpKeys
-}
-
-func (k *virtualKeyboard) appendPressed(keys []ebiten.Key) []ebiten.Key {
-	return k.appendCondition(keys, func(state keyState) bool {
-		return state.Pressed
-	})
-}
-
-func (k *virtualKeyboard) appendJustPressed(keys []ebiten.Key) []ebiten.Key {
-	return k.appendCondition(keys, func(state keyState) bool {
-		return state.Pressed && state.StateChangedCount == k.count
-	})
-}
-
-func (k *virtualKeyboard) appendJustReleased(keys []ebiten.Key) []ebiten.Key {
-	return k.appendCondition(keys, func(state keyState) bool {
-		return !state.Pressed && state.StateChangedCount == k.count
-	})
-}
-
-func (k *virtualKeyboard) appendCondition(keys []ebiten.Key, cond func(state keyState) bool) []ebiten.Key {
-	for _, key := range k.keys() {
-		state := k.states[key]
-		if cond(state) {
-			keys = append(keys, key)
-		}
-	}
-
-	return keys
-}
-
-func (k *virtualKeyboard) pressedDuration(key ebiten.Key) int {
-	state, ok := k.states[key]
-	if !ok {
-		return 0
-	}
-	if !state.Pressed {
-		return 0
-	}
-
-	return k.count - state.StateChangedCount + 1
+	k.Tick()
 }
 
 func (g *game) Layout(outsideWidth int, outsideHeight int) (screenWidth int, screenHeight int) {
@@ -113,12 +113,7 @@ func (g *game) switchMouse() {
 	if g.mouseIsVirtual {
 		setter.SetDefault()
 	} else {
-		setter.SetCursorPositionFunc(g.virtualMouse.cursorPosition)
-		setter.SetIsPressedFunc(g.virtualMouse.buttonPressed)
-		setter.SetIsJustPressedFunc(g.virtualMouse.buttonJustPressed)
-		setter.SetIsJustReleasedFunc(g.virtualMouse.buttonJustReleased)
-		setter.SetPressDurationFunc(g.virtualMouse.pressDuration)
-		setter.SetWheelFunc(g.virtualMouse.Wheel)
+		g.virtualMouse.Attach(g.mouse)
 	}
 
 	g.mouseIsVirtual = !g.mouseIsVirtual
@@ -177,7 +172,9 @@ func (g *game) drawLog(screen *ebiten.Image) {
 	}
 }
 
+// virtualMouse moves the cursor and presses buttons by a fixed pattern.
 type virtualMouse struct {
+	*nyuuryoku.VirtualMouse
 	intervals     map[ebiten.MouseButton]int
 	wheelInterval int
 	count         int
@@ -185,6 +182,7 @@ type virtualMouse struct {
 
 func newVirtualMouse() *virtualMouse {
 	return &virtualMouse{
+		VirtualMouse: nyuuryoku.NewVirtualMouse(),
 		intervals: map[ebiten.MouseButton]int{
 			ebiten.MouseButtonLeft:   60,
 			ebiten.MouseButtonRight:  90,
@@ -196,52 +194,22 @@ func newVirtualMouse() *virtualMouse {
 
 func (m *virtualMouse) Update() {
 	m.count++
-}
-
-func (m *virtualMouse) cursorPosition() (x, y int) {
-	x = m.count % screenW
-	y = m.count % screenH
-	return x, y
-}
 
-func (m *virtualMouse) buttonPressed(button ebiten.MouseButton) bool {
-	interval := m.intervals[button]
-	quotient := m.count / interval
-	return quotient%2 == 1
-}
-
-func (m *virtualMouse) buttonJustPressed(button ebiten.MouseButton) bool {
-	if !m.buttonPressed(button) {
-		return false
-	}
-	return m.duration(button) == 1
-}
+	m.MoveTo(m.count%screenW, m.count%screenH)
 
-func (m *virtualMouse) buttonJustReleased(button ebiten.MouseButton) bool {
-	if m.buttonPressed(button) {
-		return false
+	for button, interval := range m.intervals {
+		if (m.count/interval)%2 == 1 {
+			m.Press(button)
+		} else {
+			m.Release(button)
+		}
 	}
-	return m.duration(button) == 1
-}
 
-func (m *virtualMouse) pressDuration(button ebiten.MouseButton) int {
-	if !m.buttonPressed(button) {
-		return 0
+	if (m.count/m.wheelInterval)%10 == 1 {
+		m.Scroll(0.1, 0.1)
 	}
-	return m.duration(button)
-}
 
-func (m *virtualMouse) duration(button ebiten.MouseButton) int {
-	interval := m.intervals[button]
-	return m.count%interval + 1
-}
-
-func (m *virtualMouse) Wheel() (xoff, yoff float64) {
-	quotient := m.count / m.wheelInterval
-	if quotient%10 == 1 {
-		return 0.1, 0.1
-	}
-	return 0, 0
+	m.Tick()
 }
 
 func (g *game) Layout(outsideWidth int, outsideHeight int) (screenWidth int, screenHeight int) {
@@ -37,6 +37,12 @@ func generate() error {
 		"mouse.txt":    {"MouseButton"},
 		"gamepad.txt":  {"Gamepad"},
 		"keyboard.txt": {"Keys", "Key"},
+		"touch.txt":    {"Touch"},
+	}
+
+	// Types whose setters count how many times their functions are set, for detecting a switch of the input source
+	generationTypes := map[string]bool{
+		"mouse.txt": true,
 	}
 
 	entries, err := funcs.ReadDir(dirname)
@@ -48,7 +54,7 @@ func generate() error {
 		// Generate TypeName from filename
 		name := strings.TrimSuffix(e.Name(), ".txt")
 		typeName := strings.ToUpper(name[0:1]) + name[1:]
-		t := &Type{TypeName: typeName}
+		t := &Type{TypeName: typeName, HasGeneration: generationTypes[e.Name()]}
 
 		contents, err := funcs.ReadFile(path.Join(dirname, e.Name()))
 		if err != nil {
@@ -181,8 +187,9 @@ func (n FQN) Function() string {
 }
 
 type Type struct {
-	TypeName string
-	APIs     []API
+	TypeName      string
+	HasGeneration bool
+	APIs          []API
 }
 
 func (t *Type) Receiver() string {
@@ -319,6 +326,9 @@ type {{.TypeName}} struct {
 {{range .APIs -}}
 	{{.FieldName}} func({{.ArgsString}}) {{.ReturnType}}
 {{end}}
+{{- if .HasGeneration}}
+	generation int
+{{end -}}
 }
 
 func New{{.TypeName}}() *{{.TypeName}} {
@@ -334,6 +344,14 @@ func ({{.Receiver}} *{{.TypeName}}) {{.ShortenFuncName}}({{.ArgsString}}) {{.Ret
 	return {{.Receiver}}.{{.FieldName}}({{.ArgNames}})
 }
 {{end}}
+{{- if .HasGeneration}}
+
+// Generation returns the number of times the functions of {{.Receiver}} have been set by a {{.TypeName}}Setter.
+// It changes whenever the source of the input is switched, such as between a real device and a virtual one.
+func ({{.Receiver}} *{{.TypeName}}) Generation() int {
+	return {{.Receiver}}.generation
+}
+{{- end}}
 
 type {{.TypeName}}Setter struct {
 	{{.LowerCaseTypeName}} *{{.TypeName}}
@@ -352,6 +370,9 @@ func (s *{{.TypeName}}Setter) SetDefault() {
 {{range .APIs -}}
 func (s *{{.TypeName}}Setter) Set{{.ShortenFuncName}}Func(fn func({{.ArgsString}}) {{.ReturnType}}) {
 	s.{{.LowerCaseTypeName}}.{{.FieldName}} = fn
+	{{- if .HasGeneration}}
+	s.{{.LowerCaseTypeName}}.generation++
+	{{- end}}
 }
 {{end}}
 `
@@ -0,0 +1,175 @@
+package nyuuryoku
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// GestureKind is the kind of a Gesture.
+type GestureKind int
+
+const (
+	// GestureNone means that no gesture happened.
+	GestureNone GestureKind = iota
+	// GestureTap is a press released within the tap duration.
+	GestureTap
+	// GestureDoubleTap is a press that starts within the double-tap gap after the release of a tap.
+	GestureDoubleTap
+	// GestureLongPress is a press held for the long-press duration. It happens once while the button is still held.
+	GestureLongPress
+	// GestureHoldRelease is a release of a press held longer than the tap duration.
+	GestureHoldRelease
+)
+
+// Gesture is a gesture of a button.
+type Gesture struct {
+	Kind GestureKind
+
+	// HoldDuration is how many ticks the button was held, for GestureTap and GestureHoldRelease.
+	HoldDuration int
+}
+
+// GestureDetector classifies presses of a binding into taps, double-taps, long presses and releases after holds.
+// It works on any kind of binding, so the same logic covers keys, mouse buttons and gamepad buttons.
+//
+// Call Update once per tick.
+type GestureDetector struct {
+	reader  bindingReader
+	binding Binding
+
+	tapDuration       int
+	doubleTapGap      int
+	longPressDuration int
+	waitForDoubleTap  bool
+
+	duration     int
+	prevDuration int
+
+	// sinceTap is the number of ticks since the release of the last tap that can start a double-tap, or -1.
+	sinceTap int
+	// tapHold is the hold duration of the tap waiting for a double-tap.
+	tapHold int
+	// afterDoubleTap is whether the current press is the second press of a double-tap.
+	afterDoubleTap bool
+}
+
+// NewGestureDetector returns a GestureDetector of the binding.
+// The default tap duration is 12 ticks, the default double-tap gap is 15 ticks, and the default long-press duration is 30 ticks.
+// Any of the wrappers may be nil.
+func NewGestureDetector(keyboard *Keyboard, mouse *Mouse, gamepad *Gamepad, binding Binding) *GestureDetector {
+	return &GestureDetector{
+		reader: bindingReader{
+			keyboard: keyboard,
+			mouse:    mouse,
+			gamepad:  gamepad,
+		},
+		binding:           binding,
+		tapDuration:       12,
+		doubleTapGap:      15,
+		longPressDuration: 30,
+		sinceTap:          -1,
+	}
+}
+
+// SetGamepadIDs restricts the gamepads that a gamepad binding is read from.
+// With no IDs, a gamepad binding is read from all the connected gamepads.
+func (d *GestureDetector) SetGamepadIDs(ids ...ebiten.GamepadID) {
+	d.reader.setGamepadIDs(ids)
+}
+
+// SetTapDuration sets the longest press in ticks that counts as a tap.
+func (d *GestureDetector) SetTapDuration(ticks int) {
+	d.tapDuration = ticks
+}
+
+// SetDoubleTapGap sets the longest gap in ticks between the release of a tap and the next press that makes a double-tap.
+func (d *GestureDetector) SetDoubleTapGap(ticks int) {
+	d.doubleTapGap = ticks
+}
+
+// SetLongPressDuration sets how many ticks a press must be held to be a long press.
+func (d *GestureDetector) SetLongPressDuration(ticks int) {
+	d.longPressDuration = ticks
+}
+
+// SetWaitForDoubleTap sets whether a tap is reported only after the double-tap gap passes without a second press.
+// If true, a double-tap is never preceded by a tap, at the cost of the latency of taps.
+// If false, which is the default, a tap is reported at its release, and a double-tap follows it at the second press.
+func (d *GestureDetector) SetWaitForDoubleTap(wait bool) {
+	d.waitForDoubleTap = wait
+}
+
+// PressDuration returns how many ticks the binding has been held.
+func (d *GestureDetector) PressDuration() int {
+	return d.duration
+}
+
+// Update reads the binding at the current tick and returns the gesture that happened at the tick, if any.
+func (d *GestureDetector) Update() Gesture {
+	d.prevDuration = d.duration
+	if d.binding.IsAxis() {
+		if d.reader.isPressed(d.binding) {
+			d.duration++
+		} else {
+			d.duration = 0
+		}
+	} else {
+		d.duration = d.reader.pressDuration(d.binding)
+	}
+
+	if d.sinceTap >= 0 {
+		d.sinceTap++
+	}
+
+	switch {
+	case d.duration > 0 && d.prevDuration == 0:
+		// Pressed.
+		if d.sinceTap >= 0 && d.sinceTap <= d.doubleTapGap {
+			d.sinceTap = -1
+			d.afterDoubleTap = true
+			return Gesture{Kind: GestureDoubleTap}
+		}
+		d.afterDoubleTap = false
+		return d.expireTap()
+
+	case d.duration > 0:
+		// Held.
+		if d.duration == d.longPressDuration {
+			return Gesture{Kind: GestureLongPress}
+		}
+
+	case d.prevDuration > 0:
+		// Released.
+		hold := d.prevDuration
+		if hold > d.tapDuration {
+			d.afterDoubleTap = false
+			return Gesture{Kind: GestureHoldRelease, HoldDuration: hold}
+		}
+		if d.afterDoubleTap {
+			// The second press of a double-tap does not start another double-tap.
+			d.afterDoubleTap = false
+			return Gesture{}
+		}
+		d.sinceTap = 0
+		if d.waitForDoubleTap {
+			d.tapHold = hold
+			return Gesture{}
+		}
+		return Gesture{Kind: GestureTap, HoldDuration: hold}
+
+	default:
+		return d.expireTap()
+	}
+	return Gesture{}
+}
+
+// expireTap forgets the last tap once the double-tap gap has passed, and reports it if it has been waiting.
+func (d *GestureDetector) expireTap() Gesture {
+	if d.sinceTap <= d.doubleTapGap {
+		return Gesture{}
+	}
+	d.sinceTap = -1
+	if d.waitForDoubleTap {
+		return Gesture{Kind: GestureTap, HoldDuration: d.tapHold}
+	}
+	return Gesture{}
+}
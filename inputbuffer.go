@@ -0,0 +1,121 @@
+package nyuuryoku
+
+import (
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// InputBuffer remembers presses of bindings for a number of frames, so that a press slightly before it can be used,
+// such as a jump pressed just before landing, is not lost.
+//
+// A buffered press stays valid until it is consumed by Consume or it gets older than the frames of the buffer.
+// Bindings must be watched by Watch to be buffered. Call Update once per tick.
+type InputBuffer struct {
+	reader  bindingReader
+	frames  int
+	watched []Binding
+	tick    int
+
+	// pressedAt is the tick of the last press of each binding not consumed yet.
+	pressedAt map[Binding]int
+
+	// axisPressed is whether each axis binding was pressed at the previous tick, to detect its presses.
+	axisPressed map[Binding]bool
+}
+
+// NewInputBuffer returns an InputBuffer that keeps presses for frames ticks.
+// With 1 frame, a press is valid only at the tick it is just pressed.
+// Any of the wrappers may be nil.
+func NewInputBuffer(keyboard *Keyboard, mouse *Mouse, gamepad *Gamepad, frames int) *InputBuffer {
+	return &InputBuffer{
+		reader: bindingReader{
+			keyboard: keyboard,
+			mouse:    mouse,
+			gamepad:  gamepad,
+		},
+		frames:      frames,
+		pressedAt:   make(map[Binding]int),
+		axisPressed: make(map[Binding]bool),
+	}
+}
+
+// SetFrames sets how many ticks presses are kept.
+func (b *InputBuffer) SetFrames(frames int) {
+	b.frames = frames
+}
+
+// SetGamepadIDs restricts the gamepads that watched gamepad bindings are read from, in the same way as ActionMap.SetGamepadIDs.
+func (b *InputBuffer) SetGamepadIDs(ids ...ebiten.GamepadID) {
+	b.reader.setGamepadIDs(ids)
+}
+
+// Watch starts buffering presses of the bindings.
+func (b *InputBuffer) Watch(bindings ...Binding) {
+	for _, binding := range bindings {
+		if !slices.Contains(b.watched, binding) {
+			b.watched = append(b.watched, binding)
+		}
+	}
+}
+
+// Update records the presses at the current tick and forgets expired presses.
+func (b *InputBuffer) Update() {
+	b.tick++
+
+	for _, binding := range b.watched {
+		var just bool
+		if binding.IsAxis() {
+			pressed := b.reader.isPressed(binding)
+			just = pressed && !b.axisPressed[binding]
+			b.axisPressed[binding] = pressed
+		} else {
+			just = b.reader.isJustPressed(binding)
+		}
+		if just {
+			b.pressedAt[binding] = b.tick
+		}
+	}
+
+	for binding, t := range b.pressedAt {
+		if b.tick-t >= b.frames {
+			delete(b.pressedAt, binding)
+		}
+	}
+}
+
+// IsBuffered reports whether any of the bindings has a press that is not consumed and not expired.
+func (b *InputBuffer) IsBuffered(bindings ...Binding) bool {
+	_, ok := b.Age(bindings...)
+	return ok
+}
+
+// Age returns how many ticks ago the latest buffered press of the bindings was just pressed.
+// The age is 0 at the tick of the press.
+func (b *InputBuffer) Age(bindings ...Binding) (int, bool) {
+	latest := 0
+	for _, binding := range bindings {
+		if t, ok := b.pressedAt[binding]; ok {
+			latest = max(latest, t)
+		}
+	}
+	if latest == 0 {
+		return 0, false
+	}
+	return b.tick - latest, true
+}
+
+// Consume reports whether any of the bindings has a buffered press, and removes the buffered presses of all of them.
+// Pass all the bindings of an action, such as by ActionMap.Bindings, to consume a press of the action once.
+func (b *InputBuffer) Consume(bindings ...Binding) bool {
+	ok := b.IsBuffered(bindings...)
+	for _, binding := range bindings {
+		delete(b.pressedAt, binding)
+	}
+	return ok
+}
+
+// Clear removes all the buffered presses.
+func (b *InputBuffer) Clear() {
+	clear(b.pressedAt)
+}
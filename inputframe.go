@@ -0,0 +1,43 @@
+package nyuuryoku
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// InputFrame is the state of all the input devices in one tick.
+//
+// Only the state of devices is stored.
+// Just pressed and just released inputs and press durations are derived from consecutive frames.
+type InputFrame struct {
+	Keys       []ebiten.Key
+	InputChars []rune
+
+	CursorX, CursorY int
+	WheelX, WheelY   float64
+	MouseButtons     []ebiten.MouseButton
+
+	Gamepads []GamepadFrame
+}
+
+// GamepadFrame is the state of one connected gamepad in one tick.
+//
+// StandardButtons and StandardAxes are used only when Profile.StandardLayout is true.
+// StandardAxes is indexed by ebiten.StandardGamepadAxis.
+type GamepadFrame struct {
+	ID              ebiten.GamepadID
+	Profile         GamepadProfile
+	Buttons         []ebiten.GamepadButton
+	Axes            []float64
+	StandardButtons []ebiten.StandardGamepadButton
+	StandardAxes    []float64
+}
+
+// Gamepad returns the state of the gamepad id, or false if it is not connected in f.
+func (f *InputFrame) Gamepad(id ebiten.GamepadID) (*GamepadFrame, bool) {
+	for i := range f.Gamepads {
+		if f.Gamepads[i].ID == id {
+			return &f.Gamepads[i], true
+		}
+	}
+	return nil, false
+}
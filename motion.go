@@ -0,0 +1,329 @@
+package nyuuryoku
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// Direction is one of 8 directions or neutral, relative to the facing of a character.
+// The values follow the numpad notation of fighting games, where 6 is forward and 2 is down.
+type Direction int
+
+const (
+	DirectionDownBack    Direction = 1
+	DirectionDown        Direction = 2
+	DirectionDownForward Direction = 3
+	DirectionBack        Direction = 4
+	DirectionNeutral     Direction = 5
+	DirectionForward     Direction = 6
+	DirectionUpBack      Direction = 7
+	DirectionUp          Direction = 8
+	DirectionUpForward   Direction = 9
+)
+
+// QuantizeDirection returns the direction of (x, y) for a character facing right, in 8 sectors of 45 degrees.
+// y is downward as in screen coordinates and gamepad sticks. Vectors shorter than threshold are neutral.
+func QuantizeDirection(x, y, threshold float64) Direction {
+	if math.Hypot(x, y) < threshold || (x == 0 && y == 0) {
+		return DirectionNeutral
+	}
+	// Sectors counterclockwise from forward, with y flipped upward.
+	sector := int(math.Round(math.Atan2(-y, x)/(math.Pi/4))+8) % 8
+	return [...]Direction{
+		DirectionForward,
+		DirectionUpForward,
+		DirectionUp,
+		DirectionUpBack,
+		DirectionBack,
+		DirectionDownBack,
+		DirectionDown,
+		DirectionDownForward,
+	}[sector]
+}
+
+// Mirror returns d with forward and back swapped, for a character facing left.
+func (d Direction) Mirror() Direction {
+	switch d {
+	case DirectionDownBack, DirectionBack, DirectionUpBack:
+		return d + 2
+	case DirectionDownForward, DirectionForward, DirectionUpForward:
+		return d - 2
+	}
+	return d
+}
+
+// horizontal returns -1 for back, 1 for forward and 0 otherwise.
+func (d Direction) horizontal() int {
+	return (int(d)-1)%3 - 1
+}
+
+// vertical returns -1 for down, 1 for up and 0 otherwise.
+func (d Direction) vertical() int {
+	return (int(d)-1)/3 - 1
+}
+
+// DefaultMotionWindow is the window of a Motion whose Window is 0.
+const DefaultMotionWindow = 15
+
+// Motion is a sequence of directions optionally followed by a button, such as quarter-circle-forward and punch.
+//
+// Directions must be entered in order within Window frames, including the button. Other directions may be entered between them.
+// With Button, the motion is recognized at the tick the button action is just pressed.
+// Without Button, the motion is recognized at the tick the last direction is entered.
+//
+// With ChargeFrames, Charge must have been held for ChargeFrames or more within Window frames before the first direction.
+// Holding a diagonal charges both of its components, so DirectionDownBack charges DirectionBack.
+//
+// When more than one motion is recognized at the same tick, the one with the highest Priority wins,
+// and the one added first wins among the same priority.
+type Motion struct {
+	Name         string
+	Directions   []Direction
+	Button       string
+	Window       int
+	Charge       Direction
+	ChargeFrames int
+	Priority     int
+}
+
+func (m *Motion) window() int {
+	if m.Window == 0 {
+		return DefaultMotionWindow
+	}
+	return m.Window
+}
+
+// MotionRecognizer recognizes motions from a 2D composite axis and button actions of an ActionMap.
+// Call Update once per tick after ActionMap.Update.
+type MotionRecognizer struct {
+	actions    *ActionMap
+	vector     string
+	threshold  float64
+	facingLeft bool
+
+	motions []Motion
+	buttons []string
+
+	history  []motionFrame
+	tick     int
+	consumed int
+
+	// durations are how many ticks forward, back, up and down for a character facing right have been held.
+	durations [4]int
+}
+
+type motionFrame struct {
+	tick int
+
+	// direction is for a character facing right.
+	direction Direction
+	durations [4]int
+	buttons   []string
+}
+
+const (
+	componentForward = iota
+	componentBack
+	componentUp
+	componentDown
+)
+
+// NewMotionRecognizer returns a MotionRecognizer reading directions from the 2D composite axis named vector of actions.
+func NewMotionRecognizer(actions *ActionMap, vector string) *MotionRecognizer {
+	return &MotionRecognizer{
+		actions:   actions,
+		vector:    vector,
+		threshold: 0.5,
+	}
+}
+
+// SetDirectionThreshold sets the length of the vector below which the direction is neutral. The default is 0.5.
+func (r *MotionRecognizer) SetDirectionThreshold(threshold float64) {
+	r.threshold = threshold
+}
+
+// SetFacingLeft sets whether the character faces left, which mirrors forward and back of all the motions.
+func (r *MotionRecognizer) SetFacingLeft(left bool) {
+	r.facingLeft = left
+}
+
+// Add adds motions to recognize.
+func (r *MotionRecognizer) Add(motions ...Motion) {
+	for _, m := range motions {
+		r.motions = append(r.motions, m)
+		if m.Button != "" && !slices.Contains(r.buttons, m.Button) {
+			r.buttons = append(r.buttons, m.Button)
+		}
+	}
+	// Keep the order of addition among the same priority.
+	slices.SortStableFunc(r.motions, func(a, b Motion) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+}
+
+// Direction returns the current direction relative to the facing.
+func (r *MotionRecognizer) Direction() Direction {
+	if len(r.history) == 0 {
+		return DirectionNeutral
+	}
+	return r.relative(r.history[len(r.history)-1].direction)
+}
+
+// Reset forgets the input history.
+func (r *MotionRecognizer) Reset() {
+	r.history = r.history[:0]
+	r.consumed = r.tick
+}
+
+// Update reads the input of the current tick and returns the name of the motion recognized at the tick, if any.
+// Input used by a recognized motion is not used by later motions.
+func (r *MotionRecognizer) Update() (string, bool) {
+	r.tick++
+
+	x, y := r.actions.Vector(r.vector)
+	d := QuantizeDirection(x, y, r.threshold)
+	for c, held := range [...]bool{
+		componentForward: d.horizontal() > 0,
+		componentBack:    d.horizontal() < 0,
+		componentUp:      d.vertical() > 0,
+		componentDown:    d.vertical() < 0,
+	} {
+		if held {
+			r.durations[c]++
+		} else {
+			r.durations[c] = 0
+		}
+	}
+
+	var buttons []string
+	for _, b := range r.buttons {
+		if r.actions.IsJustPressed(b) {
+			buttons = append(buttons, b)
+		}
+	}
+
+	r.history = append(r.history, motionFrame{
+		tick:      r.tick,
+		direction: d,
+		durations: r.durations,
+		buttons:   buttons,
+	})
+	r.trimHistory()
+
+	for i := range r.motions {
+		m := &r.motions[i]
+		if r.matches(m) {
+			r.consumed = r.tick
+			return m.Name, true
+		}
+	}
+	return "", false
+}
+
+// trimHistory drops frames that no motion can use anymore.
+func (r *MotionRecognizer) trimHistory() {
+	n := 1
+	for i := range r.motions {
+		n = max(n, 2*r.motions[i].window())
+	}
+	if len(r.history) > n {
+		r.history = slices.Delete(r.history, 0, len(r.history)-n)
+	}
+}
+
+func (r *MotionRecognizer) relative(d Direction) Direction {
+	if r.facingLeft {
+		return d.Mirror()
+	}
+	return d
+}
+
+func (r *MotionRecognizer) matches(m *Motion) bool {
+	last := len(r.history) - 1
+	cur := &r.history[last]
+
+	if m.Button != "" {
+		if !slices.Contains(cur.buttons, m.Button) {
+			return false
+		}
+	} else {
+		if len(m.Directions) == 0 {
+			return false
+		}
+		d := m.Directions[len(m.Directions)-1]
+		if r.relative(cur.direction) != d {
+			return false
+		}
+		if last > 0 && r.relative(r.history[last-1].direction) == d {
+			return false
+		}
+	}
+
+	// Match the directions backward, so that each is matched as late as possible.
+	from := max(r.tick-m.window()+1, r.consumed+1)
+	first := last + 1
+	k := len(m.Directions) - 1
+	for i := last; i >= 0 && k >= 0; i-- {
+		f := &r.history[i]
+		if f.tick < from {
+			return false
+		}
+		if r.relative(f.direction) == m.Directions[k] {
+			first = i
+			k--
+		}
+	}
+	if k >= 0 {
+		return false
+	}
+
+	if m.ChargeFrames > 0 {
+		return r.isCharged(m, first)
+	}
+	return true
+}
+
+// isCharged reports whether the charge of m was complete at a frame within the window before the frame at index first.
+// For a motion without directions, first is len(r.history) and the current frame counts.
+func (r *MotionRecognizer) isCharged(m *Motion, first int) bool {
+	end := r.tick
+	if first < len(r.history) {
+		end = r.history[first].tick
+	}
+	for i := first - 1; i >= 0; i-- {
+		f := &r.history[i]
+		if f.tick < end-m.window() || f.tick <= r.consumed {
+			break
+		}
+		if r.chargeDuration(m.Charge, f.durations) >= m.ChargeFrames {
+			return true
+		}
+	}
+	return false
+}
+
+// chargeDuration returns how long all the components of the relative direction d have been held.
+func (r *MotionRecognizer) chargeDuration(d Direction, durations [4]int) int {
+	h := d.horizontal()
+	if r.facingLeft {
+		h = -h
+	}
+	duration := math.MaxInt
+	switch {
+	case h > 0:
+		duration = min(duration, durations[componentForward])
+	case h < 0:
+		duration = min(duration, durations[componentBack])
+	}
+	switch v := d.vertical(); {
+	case v > 0:
+		duration = min(duration, durations[componentUp])
+	case v < 0:
+		duration = min(duration, durations[componentDown])
+	}
+	if duration == math.MaxInt {
+		return 0
+	}
+	return duration
+}
@@ -14,6 +14,8 @@ type Mouse struct {
 	isMouseButtonJustReleasedFn func(button ebiten.MouseButton) bool
 	mouseButtonPressDurationFn  func(button ebiten.MouseButton) int
 	wheelFn                     func() (float64, float64)
+
+	generation int
 }
 
 func NewMouse() *Mouse {
@@ -43,6 +45,12 @@ func (m *Mouse) Wheel() (float64, float64) {
 	return m.wheelFn()
 }
 
+// Generation returns the number of times the functions of m have been set by a MouseSetter.
+// It changes whenever the source of the input is switched, such as between a real device and a virtual one.
+func (m *Mouse) Generation() int {
+	return m.generation
+}
+
 type MouseSetter struct {
 	mouse *Mouse
 }
@@ -63,19 +71,25 @@ func (s *MouseSetter) SetDefault() {
 
 func (s *MouseSetter) SetCursorPositionFunc(fn func() (int, int)) {
 	s.mouse.cursorPositionFn = fn
+	s.mouse.generation++
 }
 func (s *MouseSetter) SetIsPressedFunc(fn func(mouseButton ebiten.MouseButton) bool) {
 	s.mouse.isMouseButtonPressedFn = fn
+	s.mouse.generation++
 }
 func (s *MouseSetter) SetIsJustPressedFunc(fn func(button ebiten.MouseButton) bool) {
 	s.mouse.isMouseButtonJustPressedFn = fn
+	s.mouse.generation++
 }
 func (s *MouseSetter) SetIsJustReleasedFunc(fn func(button ebiten.MouseButton) bool) {
 	s.mouse.isMouseButtonJustReleasedFn = fn
+	s.mouse.generation++
 }
 func (s *MouseSetter) SetPressDurationFunc(fn func(button ebiten.MouseButton) int) {
 	s.mouse.mouseButtonPressDurationFn = fn
+	s.mouse.generation++
 }
 func (s *MouseSetter) SetWheelFunc(fn func() (float64, float64)) {
 	s.mouse.wheelFn = fn
+	s.mouse.generation++
 }
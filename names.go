@@ -0,0 +1,109 @@
+package nyuuryoku
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Names of inputs are the names of Ebitengine's constants without their type prefix,
+// such as "Space" for ebiten.KeySpace and "RightBottom" for ebiten.StandardGamepadButtonRightBottom.
+// Parsers also accept the names with the prefix.
+
+var mouseButtonNames = []string{
+	ebiten.MouseButtonLeft:   "Left",
+	ebiten.MouseButtonMiddle: "Middle",
+	ebiten.MouseButtonRight:  "Right",
+	ebiten.MouseButton3:      "3",
+	ebiten.MouseButton4:      "4",
+}
+
+var standardGamepadButtonNames = []string{
+	ebiten.StandardGamepadButtonRightBottom:      "RightBottom",
+	ebiten.StandardGamepadButtonRightRight:       "RightRight",
+	ebiten.StandardGamepadButtonRightLeft:        "RightLeft",
+	ebiten.StandardGamepadButtonRightTop:         "RightTop",
+	ebiten.StandardGamepadButtonFrontTopLeft:     "FrontTopLeft",
+	ebiten.StandardGamepadButtonFrontTopRight:    "FrontTopRight",
+	ebiten.StandardGamepadButtonFrontBottomLeft:  "FrontBottomLeft",
+	ebiten.StandardGamepadButtonFrontBottomRight: "FrontBottomRight",
+	ebiten.StandardGamepadButtonCenterLeft:       "CenterLeft",
+	ebiten.StandardGamepadButtonCenterRight:      "CenterRight",
+	ebiten.StandardGamepadButtonLeftStick:        "LeftStick",
+	ebiten.StandardGamepadButtonRightStick:       "RightStick",
+	ebiten.StandardGamepadButtonLeftTop:          "LeftTop",
+	ebiten.StandardGamepadButtonLeftBottom:       "LeftBottom",
+	ebiten.StandardGamepadButtonLeftLeft:         "LeftLeft",
+	ebiten.StandardGamepadButtonLeftRight:        "LeftRight",
+	ebiten.StandardGamepadButtonCenterCenter:     "CenterCenter",
+}
+
+var standardGamepadAxisNames = []string{
+	ebiten.StandardGamepadAxisLeftStickHorizontal:  "LeftStickHorizontal",
+	ebiten.StandardGamepadAxisLeftStickVertical:    "LeftStickVertical",
+	ebiten.StandardGamepadAxisRightStickHorizontal: "RightStickHorizontal",
+	ebiten.StandardGamepadAxisRightStickVertical:   "RightStickVertical",
+}
+
+func keyName(key ebiten.Key) string {
+	return key.String()
+}
+
+func parseKeyName(name string) (ebiten.Key, bool) {
+	var k ebiten.Key
+	if err := k.UnmarshalText([]byte(name)); err == nil {
+		return k, true
+	}
+	if s, ok := strings.CutPrefix(name, "Key"); ok {
+		if err := k.UnmarshalText([]byte(s)); err == nil {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+func mouseButtonName(button ebiten.MouseButton) string {
+	return nameOf(mouseButtonNames, int(button))
+}
+
+func parseMouseButtonName(name string) (ebiten.MouseButton, bool) {
+	i, ok := parseName(mouseButtonNames, "MouseButton", name)
+	return ebiten.MouseButton(i), ok
+}
+
+func standardGamepadButtonName(button ebiten.StandardGamepadButton) string {
+	return nameOf(standardGamepadButtonNames, int(button))
+}
+
+func parseStandardGamepadButtonName(name string) (ebiten.StandardGamepadButton, bool) {
+	i, ok := parseName(standardGamepadButtonNames, "StandardGamepadButton", name)
+	return ebiten.StandardGamepadButton(i), ok
+}
+
+func standardGamepadAxisName(axis ebiten.StandardGamepadAxis) string {
+	return nameOf(standardGamepadAxisNames, int(axis))
+}
+
+func parseStandardGamepadAxisName(name string) (ebiten.StandardGamepadAxis, bool) {
+	i, ok := parseName(standardGamepadAxisNames, "StandardGamepadAxis", name)
+	return ebiten.StandardGamepadAxis(i), ok
+}
+
+// nameOf returns names[i], or i in decimal if names has no name for i.
+func nameOf(names []string, i int) string {
+	if i < 0 || i >= len(names) {
+		return strconv.Itoa(i)
+	}
+	return names[i]
+}
+
+func parseName(names []string, prefix, name string) (int, bool) {
+	name = strings.TrimPrefix(name, prefix)
+	for i, n := range names {
+		if n == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
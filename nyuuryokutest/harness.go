@@ -0,0 +1,120 @@
+// Package nyuuryokutest provides utilities to test games that read input through nyuuryoku.
+package nyuuryokutest
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/noppikinatta/nyuuryoku"
+)
+
+// Harness runs the Update of an ebiten.Game without ebiten.RunGame, feeding it input from virtual devices.
+//
+// Pass Keyboard, Mouse, Gamepad and Touch to the game instead of creating new ones.
+// Changes to the virtual devices made before Step become visible in the Update called by that Step,
+// in the same way as real input changed between two ticks.
+type Harness struct {
+	Keyboard *nyuuryoku.Keyboard
+	Mouse    *nyuuryoku.Mouse
+	Gamepad  *nyuuryoku.Gamepad
+	Touch    *nyuuryoku.Touch
+
+	VirtualKeyboard *nyuuryoku.VirtualKeyboard
+	VirtualMouse    *nyuuryoku.VirtualMouse
+	VirtualGamepad  *nyuuryoku.VirtualGamepad
+	VirtualTouch    *nyuuryoku.VirtualTouch
+
+	tick      int
+	script    []nyuuryoku.InputFrame
+	scriptPos int
+}
+
+// NewHarness returns a Harness whose wrappers are attached to its virtual devices.
+func NewHarness() *Harness {
+	h := &Harness{
+		Keyboard: nyuuryoku.NewKeyboard(),
+		Mouse:    nyuuryoku.NewMouse(),
+		Gamepad:  nyuuryoku.NewGamepad(),
+		Touch:    nyuuryoku.NewTouch(),
+
+		VirtualKeyboard: nyuuryoku.NewVirtualKeyboard(),
+		VirtualMouse:    nyuuryoku.NewVirtualMouse(),
+		VirtualGamepad:  nyuuryoku.NewVirtualGamepad(),
+		VirtualTouch:    nyuuryoku.NewVirtualTouch(),
+	}
+
+	h.VirtualKeyboard.Attach(h.Keyboard)
+	h.VirtualMouse.Attach(h.Mouse)
+	h.VirtualGamepad.Attach(h.Gamepad)
+	h.VirtualTouch.Attach(h.Touch)
+
+	return h
+}
+
+// Tick returns the number of ticks run so far.
+func (h *Harness) Tick() int {
+	return h.tick
+}
+
+// PlayScript feeds frames to the virtual keyboard, mouse and gamepad, one frame per Step from the next Step.
+// While a script is playing, it overrides the state of those devices set by their methods.
+// After the last frame, the devices keep the state of the last frame.
+func (h *Harness) PlayScript(frames []nyuuryoku.InputFrame) {
+	h.script = frames
+	h.scriptPos = 0
+}
+
+// LoadScript parses an input script from r and plays it by PlayScript.
+func (h *Harness) LoadScript(r io.Reader) error {
+	frames, err := nyuuryoku.ParseScript(r)
+	if err != nil {
+		return err
+	}
+	h.PlayScript(frames)
+	return nil
+}
+
+// IsPlayingScript reports whether the script set by PlayScript has frames not fed yet.
+func (h *Harness) IsPlayingScript() bool {
+	return h.scriptPos < len(h.script)
+}
+
+// Step advances the virtual devices by one tick and calls the Update of game.
+func (h *Harness) Step(game ebiten.Game) error {
+	if h.IsPlayingScript() {
+		nyuuryoku.ApplyInputFrame(&h.script[h.scriptPos], h.VirtualKeyboard, h.VirtualMouse, h.VirtualGamepad)
+		h.scriptPos++
+	}
+
+	h.VirtualKeyboard.Tick()
+	h.VirtualMouse.Tick()
+	h.VirtualGamepad.Tick()
+	h.VirtualTouch.Tick()
+	h.tick++
+
+	if err := game.Update(); err != nil {
+		return fmt.Errorf("nyuuryokutest: Update at tick %d: %w", h.tick, err)
+	}
+	return nil
+}
+
+// Run calls Step n times. Run stops at the first error returned by the Update of game.
+func (h *Harness) Run(game ebiten.Game, n int) error {
+	for range n {
+		if err := h.Step(game); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunScript calls Step until all the frames of the script set by PlayScript are fed.
+func (h *Harness) RunScript(game ebiten.Game) error {
+	for h.IsPlayingScript() {
+		if err := h.Step(game); err != nil {
+			return err
+		}
+	}
+	return nil
+}
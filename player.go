@@ -0,0 +1,178 @@
+package nyuuryoku
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Player feeds recorded frames back to a Keyboard, a Mouse and a Gamepad through their setters.
+//
+// Just pressed and just released inputs and press durations are derived from consecutive frames,
+// so a session captured by Recorder is replayed frame for frame.
+// Any of the wrappers may be nil, in which case the device is not replayed.
+type Player struct {
+	keyboard *Keyboard
+	mouse    *Mouse
+	gamepad  *Gamepad
+
+	frames     []InputFrame
+	pos        int
+	playing    bool
+	loop       bool
+	onFinished func()
+
+	virtualKeyboard *VirtualKeyboard
+	virtualMouse    *VirtualMouse
+	virtualGamepad  *VirtualGamepad
+}
+
+func NewPlayer(frames []InputFrame, keyboard *Keyboard, mouse *Mouse, gamepad *Gamepad) *Player {
+	return &Player{
+		keyboard: keyboard,
+		mouse:    mouse,
+		gamepad:  gamepad,
+		frames:   frames,
+	}
+}
+
+// SetLoop sets whether the playback restarts from the first frame after the last frame.
+func (p *Player) SetLoop(loop bool) {
+	p.loop = loop
+}
+
+// SetOnFinished sets a function called when the playback reaches the end without looping.
+func (p *Player) SetOnFinished(fn func()) {
+	p.onFinished = fn
+}
+
+// Start installs the player into the wrappers and rewinds to the first frame.
+// The first frame is fed at the next Update.
+func (p *Player) Start() {
+	p.virtualKeyboard = NewVirtualKeyboard()
+	p.virtualMouse = NewVirtualMouse()
+	p.virtualGamepad = NewVirtualGamepad()
+
+	if p.keyboard != nil {
+		p.virtualKeyboard.Attach(p.keyboard)
+	}
+	if p.mouse != nil {
+		p.virtualMouse.Attach(p.mouse)
+	}
+	if p.gamepad != nil {
+		p.virtualGamepad.Attach(p.gamepad)
+	}
+
+	p.pos = 0
+	p.playing = true
+}
+
+// Stop reverts the wrappers to the real devices by their SetDefault.
+func (p *Player) Stop() {
+	if !p.playing {
+		return
+	}
+	p.playing = false
+
+	if p.keyboard != nil {
+		NewKeyboardSetter(p.keyboard).SetDefault()
+	}
+	if p.mouse != nil {
+		NewMouseSetter(p.mouse).SetDefault()
+	}
+	if p.gamepad != nil {
+		NewGamepadSetter(p.gamepad).SetDefault()
+	}
+}
+
+// IsPlaying reports whether the player is installed into the wrappers.
+func (p *Player) IsPlaying() bool {
+	return p.playing
+}
+
+// Position returns the index of the frame to be fed at the next Update.
+func (p *Player) Position() int {
+	return p.pos
+}
+
+// Update feeds the next frame to the wrappers.
+// Call Update exactly once at the beginning of every Update of the game.
+//
+// When the last frame has been fed and looping is disabled,
+// Update stops the player, reverts the wrappers to the real devices and calls the function set by SetOnFinished.
+func (p *Player) Update() {
+	if !p.playing {
+		return
+	}
+
+	if p.pos >= len(p.frames) {
+		if !p.loop || len(p.frames) == 0 {
+			p.Stop()
+			if p.onFinished != nil {
+				p.onFinished()
+			}
+			return
+		}
+		p.pos = 0
+	}
+
+	p.feed(&p.frames[p.pos])
+	p.pos++
+}
+
+func (p *Player) feed(f *InputFrame) {
+	ApplyInputFrame(f, p.virtualKeyboard, p.virtualMouse, p.virtualGamepad)
+	p.virtualKeyboard.Tick()
+	p.virtualMouse.Tick()
+	p.virtualGamepad.Tick()
+}
+
+// ApplyInputFrame sets the state of f to the virtual devices.
+// The state becomes visible at their next Tick. Any of the devices may be nil.
+func ApplyInputFrame(f *InputFrame, keyboard *VirtualKeyboard, mouse *VirtualMouse, gamepad *VirtualGamepad) {
+	if keyboard != nil {
+		keyboard.ReleaseAll()
+		// Press the left and right keys first so that KeyShift and the like do not add a left key.
+		for _, k := range f.Keys {
+			if _, ok := sidedModifierKeys[k]; !ok {
+				keyboard.Press(k)
+			}
+		}
+		for _, k := range f.Keys {
+			if _, ok := sidedModifierKeys[k]; ok {
+				keyboard.Press(k)
+			}
+		}
+		keyboard.TypeRunes(f.InputChars...)
+	}
+
+	if mouse != nil {
+		mouse.MoveTo(f.CursorX, f.CursorY)
+		mouse.Scroll(f.WheelX, f.WheelY)
+		mouse.ReleaseAll()
+		for _, b := range f.MouseButtons {
+			mouse.Press(b)
+		}
+	}
+
+	if gamepad != nil {
+		for id := range gamepad.devices {
+			if _, ok := f.Gamepad(id); !ok {
+				gamepad.Disconnect(id)
+			}
+		}
+		for _, gf := range f.Gamepads {
+			gamepad.connectWithID(gf.ID, gf.Profile)
+			for _, b := range gf.Buttons {
+				gamepad.PressButton(gf.ID, b)
+			}
+			for a, v := range gf.Axes {
+				gamepad.SetAxisValue(gf.ID, a, v)
+			}
+			for _, b := range gf.StandardButtons {
+				gamepad.PressStandardButton(gf.ID, b)
+			}
+			for a, v := range gf.StandardAxes {
+				gamepad.SetStandardAxisValue(gf.ID, ebiten.StandardGamepadAxis(a), v)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package nyuuryoku
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Recorder captures the input state of a Keyboard, a Mouse and a Gamepad once per tick.
+//
+// Recorder reads through the wrappers, so it records whatever source is set to them by their setters.
+// Any of the wrappers may be nil, in which case the device is not recorded.
+type Recorder struct {
+	keyboard *Keyboard
+	mouse    *Mouse
+	gamepad  *Gamepad
+	frames   []InputFrame
+	tmpIDs   []ebiten.GamepadID
+}
+
+func NewRecorder(keyboard *Keyboard, mouse *Mouse, gamepad *Gamepad) *Recorder {
+	return &Recorder{
+		keyboard: keyboard,
+		mouse:    mouse,
+		gamepad:  gamepad,
+	}
+}
+
+// Record captures the input state of the current tick.
+// Call Record exactly once in every Update.
+func (r *Recorder) Record() {
+	f := InputFrame{}
+
+	if r.keyboard != nil {
+		f.Keys = r.keyboard.AppendPressed(nil)
+		f.InputChars = r.keyboard.AppendInputChars(nil)
+	}
+
+	if r.mouse != nil {
+		f.CursorX, f.CursorY = r.mouse.CursorPosition()
+		f.WheelX, f.WheelY = r.mouse.Wheel()
+		for b := ebiten.MouseButton(0); b <= ebiten.MouseButtonMax; b++ {
+			if r.mouse.IsPressed(b) {
+				f.MouseButtons = append(f.MouseButtons, b)
+			}
+		}
+	}
+
+	if r.gamepad != nil {
+		r.tmpIDs = r.gamepad.AppendIDs(r.tmpIDs[:0])
+		for _, id := range r.tmpIDs {
+			f.Gamepads = append(f.Gamepads, r.recordGamepad(id))
+		}
+	}
+
+	r.frames = append(r.frames, f)
+}
+
+func (r *Recorder) recordGamepad(id ebiten.GamepadID) GamepadFrame {
+	g := r.gamepad
+	f := GamepadFrame{
+		ID: id,
+		Profile: GamepadProfile{
+			Name:           g.Name(id),
+			SDLID:          g.SDLID(id),
+			ButtonCount:    g.ButtonCount(id),
+			AxisCount:      g.AxisCount(id),
+			StandardLayout: g.IsStandardLayoutAvailable(id),
+		},
+	}
+
+	f.Buttons = g.AppendPressedButtons(id, nil)
+	if f.Profile.AxisCount > 0 {
+		f.Axes = make([]float64, f.Profile.AxisCount)
+		for a := range f.Axes {
+			f.Axes[a] = g.AxisValue(id, a)
+		}
+	}
+
+	if f.Profile.StandardLayout {
+		f.StandardButtons = g.AppendPressedStandardButtons(id, nil)
+		f.StandardAxes = make([]float64, ebiten.StandardGamepadAxisMax+1)
+		for a := range f.StandardAxes {
+			f.StandardAxes[a] = g.StandardAxisValue(id, ebiten.StandardGamepadAxis(a))
+		}
+	}
+
+	return f
+}
+
+// Frames returns the frames recorded so far.
+// The returned slice must not be modified.
+func (r *Recorder) Frames() []InputFrame {
+	return r.frames
+}
+
+// Reset discards all the recorded frames.
+func (r *Recorder) Reset() {
+	r.frames = nil
+}
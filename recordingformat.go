@@ -0,0 +1,851 @@
+package nyuuryoku
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// The binary recording format
+//
+// A recording starts with a header:
+//
+//	magic         "NYRC"
+//	version       uvarint (RecordingFormatVersion)
+//	tps           uvarint
+//	screen width  uvarint
+//	screen height uvarint
+//	descriptors   uvarint count, then count gamepad descriptors
+//
+// A gamepad descriptor is:
+//
+//	name         string
+//	SDL ID       string
+//	button count uvarint
+//	axis count   uvarint
+//	flags        byte (bit 0: standard layout)
+//
+// Strings are a uvarint byte length followed by UTF-8 bytes.
+// Strings longer than 1024 bytes, button counts greater than ebiten.GamepadButtonMax+1
+// and axis counts greater than 64 are rejected as corrupt.
+//
+// The header is followed by records. Each record starts with a uvarint gap,
+// the number of frames before the record that are the same as the previous frame with no input chars and no wheel movement.
+// The gap is followed by operations, each starting with an opcode byte, that change the previous frame into the new frame.
+// The record ends with opEndFrame, or with opEndStream for the last record, whose gap carries the trailing frames.
+//
+// Keys are referred to by an index into a key table. opDefineKey appends a key name to the table the first time a key is used,
+// so key codes that differ between Ebitengine versions do not break old recordings.
+// Gamepads with a profile not in the header are appended to the descriptor table by opDefineGamepad.
+//
+// Axis values are quantized to multiples of 1/32768, and wheel offsets to multiples of 1/256.
+// Cursor positions are stored as deltas from the previous frame.
+
+// RecordingFormatVersion is the version of the binary recording format written by RecordingEncoder.
+const RecordingFormatVersion = 1
+
+const recordingMagic = "NYRC"
+
+const (
+	axisQuantum  = 32768
+	wheelQuantum = 256
+)
+
+// Limits of the lengths and counts read from a recording, so that a corrupt recording cannot cause huge allocations.
+const (
+	maxRecordingStringLength = 1024
+	maxRecordingButtonCount  = int(ebiten.GamepadButtonMax) + 1
+	maxRecordingAxisCount    = 64
+)
+
+const (
+	opEndFrame byte = iota
+	opEndStream
+	opDefineKey
+	opDefineGamepad
+	opKeyDown
+	opKeyUp
+	opInputChars
+	opCursor
+	opWheel
+	opMouseButtonDown
+	opMouseButtonUp
+	opGamepadConnect
+	opGamepadDisconnect
+	opGamepadButtonDown
+	opGamepadButtonUp
+	opGamepadAxis
+	opStandardGamepadButtonDown
+	opStandardGamepadButtonUp
+	opStandardGamepadAxis
+)
+
+// RecordingHeader is the header of a binary recording.
+//
+// Gamepads lists the profiles of the gamepads known when the recording starts.
+// Profiles of gamepads connected later are added to the recording as they appear.
+type RecordingHeader struct {
+	Version      int
+	TPS          int
+	ScreenWidth  int
+	ScreenHeight int
+	Gamepads     []GamepadProfile
+}
+
+// recordingState is the state of the previous frame shared by RecordingEncoder and RecordingDecoder.
+type recordingState struct {
+	keys         []bool
+	keyTable     []ebiten.Key
+	keyIndices   map[ebiten.Key]int
+	mouseButtons []bool
+	cursorX      int
+	cursorY      int
+	profiles     []GamepadProfile
+	gamepads     map[ebiten.GamepadID]*recordingGamepadState
+}
+
+type recordingGamepadState struct {
+	profileIndex    int
+	buttons         []bool
+	axes            []int
+	standardButtons []bool
+	standardAxes    []int
+}
+
+func newRecordingState(profiles []GamepadProfile) *recordingState {
+	return &recordingState{
+		keys:         make([]bool, ebiten.KeyMax+1),
+		keyIndices:   make(map[ebiten.Key]int),
+		mouseButtons: make([]bool, ebiten.MouseButtonMax+1),
+		profiles:     slices.Clone(profiles),
+		gamepads:     make(map[ebiten.GamepadID]*recordingGamepadState),
+	}
+}
+
+func newRecordingGamepadState(profileIndex int, profile GamepadProfile) *recordingGamepadState {
+	return &recordingGamepadState{
+		profileIndex:    profileIndex,
+		buttons:         make([]bool, ebiten.GamepadButtonMax+1),
+		axes:            make([]int, max(profile.AxisCount, 0)),
+		standardButtons: make([]bool, ebiten.StandardGamepadButtonMax+1),
+		standardAxes:    make([]int, ebiten.StandardGamepadAxisMax+1),
+	}
+}
+
+func quantize(value float64, quantum int) int {
+	return int(math.Round(value * float64(quantum)))
+}
+
+func quantizeAxis(value float64) int {
+	return quantize(max(-1, min(1, value)), axisQuantum)
+}
+
+func dequantize(value int, quantum int) float64 {
+	return float64(value) / float64(quantum)
+}
+
+// WriteRecording writes header and all the frames to w in the binary recording format.
+func WriteRecording(w io.Writer, header RecordingHeader, frames []InputFrame) error {
+	e, err := NewRecordingEncoder(w, header)
+	if err != nil {
+		return err
+	}
+	for i := range frames {
+		if err := e.Encode(&frames[i]); err != nil {
+			return err
+		}
+	}
+	return e.Close()
+}
+
+// ReadRecording reads a whole recording in the binary recording format from r.
+func ReadRecording(r io.Reader) (RecordingHeader, []InputFrame, error) {
+	d, err := NewRecordingDecoder(r)
+	if err != nil {
+		return RecordingHeader{}, nil, err
+	}
+
+	var frames []InputFrame
+	for {
+		f, err := d.Decode()
+		if err == io.EOF {
+			return d.Header(), frames, nil
+		}
+		if err != nil {
+			return RecordingHeader{}, nil, err
+		}
+		frames = append(frames, f)
+	}
+}
+
+// RecordingEncoder writes frames to an io.Writer in the binary recording format.
+type RecordingEncoder struct {
+	w     *bufio.Writer
+	state *recordingState
+	ops   []byte
+	gap   int
+	err   error
+}
+
+// NewRecordingEncoder writes the header to w and returns an encoder to write frames after it.
+// The Version field of header is ignored and RecordingFormatVersion is written.
+func NewRecordingEncoder(w io.Writer, header RecordingHeader) (*RecordingEncoder, error) {
+	e := &RecordingEncoder{
+		w:     bufio.NewWriter(w),
+		state: newRecordingState(header.Gamepads),
+	}
+
+	b := []byte(recordingMagic)
+	b = binary.AppendUvarint(b, RecordingFormatVersion)
+	b = binary.AppendUvarint(b, uint64(header.TPS))
+	b = binary.AppendUvarint(b, uint64(header.ScreenWidth))
+	b = binary.AppendUvarint(b, uint64(header.ScreenHeight))
+	b = binary.AppendUvarint(b, uint64(len(header.Gamepads)))
+	for _, p := range header.Gamepads {
+		b = appendGamepadProfile(b, p)
+	}
+
+	if _, err := e.w.Write(b); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Encode writes the next frame.
+func (e *RecordingEncoder) Encode(f *InputFrame) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	e.ops = e.appendOps(e.ops[:0], f)
+	if len(e.ops) == 0 {
+		e.gap++
+		return nil
+	}
+
+	e.err = e.writeRecord(opEndFrame)
+	return e.err
+}
+
+// Close writes the end of the recording and flushes the buffered data.
+// Close does not close the underlying writer.
+func (e *RecordingEncoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+
+	e.ops = e.ops[:0]
+	if err := e.writeRecord(opEndStream); err != nil {
+		e.err = err
+		return err
+	}
+
+	e.err = errors.New("nyuuryoku: RecordingEncoder is already closed")
+	return e.w.Flush()
+}
+
+func (e *RecordingEncoder) writeRecord(end byte) error {
+	b := binary.AppendUvarint(nil, uint64(e.gap))
+	if _, err := e.w.Write(b); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(e.ops); err != nil {
+		return err
+	}
+	if err := e.w.WriteByte(end); err != nil {
+		return err
+	}
+	e.gap = 0
+	return nil
+}
+
+func (e *RecordingEncoder) appendOps(b []byte, f *InputFrame) []byte {
+	s := e.state
+
+	pressedKeys := make([]bool, ebiten.KeyMax+1)
+	for _, k := range f.Keys {
+		if k >= 0 && k <= ebiten.KeyMax {
+			pressedKeys[k] = true
+		}
+	}
+	for k := range pressedKeys {
+		if pressedKeys[k] == s.keys[k] {
+			continue
+		}
+		s.keys[k] = pressedKeys[k]
+
+		idx, ok := s.keyIndices[ebiten.Key(k)]
+		if !ok {
+			idx = len(s.keyTable)
+			s.keyIndices[ebiten.Key(k)] = idx
+			s.keyTable = append(s.keyTable, ebiten.Key(k))
+			b = append(b, opDefineKey)
+			b = appendString(b, ebiten.Key(k).String())
+		}
+
+		if pressedKeys[k] {
+			b = append(b, opKeyDown)
+		} else {
+			b = append(b, opKeyUp)
+		}
+		b = binary.AppendUvarint(b, uint64(idx))
+	}
+
+	if len(f.InputChars) > 0 {
+		b = append(b, opInputChars)
+		b = binary.AppendUvarint(b, uint64(len(f.InputChars)))
+		for _, r := range f.InputChars {
+			b = binary.AppendUvarint(b, uint64(r))
+		}
+	}
+
+	if f.CursorX != s.cursorX || f.CursorY != s.cursorY {
+		b = append(b, opCursor)
+		b = binary.AppendVarint(b, int64(f.CursorX-s.cursorX))
+		b = binary.AppendVarint(b, int64(f.CursorY-s.cursorY))
+		s.cursorX, s.cursorY = f.CursorX, f.CursorY
+	}
+
+	wx, wy := quantize(f.WheelX, wheelQuantum), quantize(f.WheelY, wheelQuantum)
+	if wx != 0 || wy != 0 {
+		b = append(b, opWheel)
+		b = binary.AppendVarint(b, int64(wx))
+		b = binary.AppendVarint(b, int64(wy))
+	}
+
+	pressedButtons := make([]bool, ebiten.MouseButtonMax+1)
+	for _, mb := range f.MouseButtons {
+		if mb >= 0 && mb <= ebiten.MouseButtonMax {
+			pressedButtons[mb] = true
+		}
+	}
+	b = appendButtonOps(b, opMouseButtonDown, opMouseButtonUp, nil, s.mouseButtons, pressedButtons)
+
+	for id := range s.gamepads {
+		if _, ok := f.Gamepad(id); !ok {
+			delete(s.gamepads, id)
+			b = append(b, opGamepadDisconnect)
+			b = binary.AppendUvarint(b, uint64(id))
+		}
+	}
+
+	for i := range f.Gamepads {
+		b = e.appendGamepadOps(b, &f.Gamepads[i])
+	}
+
+	return b
+}
+
+func (e *RecordingEncoder) appendGamepadOps(b []byte, f *GamepadFrame) []byte {
+	s := e.state
+	id := binary.AppendUvarint(nil, uint64(f.ID))
+
+	profileIndex := slices.Index(s.profiles, f.Profile)
+	if profileIndex < 0 {
+		profileIndex = len(s.profiles)
+		s.profiles = append(s.profiles, f.Profile)
+		b = append(b, opDefineGamepad)
+		b = appendGamepadProfile(b, f.Profile)
+	}
+
+	g, ok := s.gamepads[f.ID]
+	if !ok || g.profileIndex != profileIndex {
+		g = newRecordingGamepadState(profileIndex, f.Profile)
+		s.gamepads[f.ID] = g
+		b = append(b, opGamepadConnect)
+		b = append(b, id...)
+		b = binary.AppendUvarint(b, uint64(profileIndex))
+	}
+
+	pressed := make([]bool, ebiten.GamepadButtonMax+1)
+	for _, btn := range f.Buttons {
+		if btn >= 0 && btn <= ebiten.GamepadButtonMax {
+			pressed[btn] = true
+		}
+	}
+	b = appendButtonOps(b, opGamepadButtonDown, opGamepadButtonUp, id, g.buttons, pressed)
+	b = appendAxisOps(b, opGamepadAxis, id, g.axes, f.Axes)
+
+	if !f.Profile.StandardLayout {
+		return b
+	}
+
+	pressed = make([]bool, ebiten.StandardGamepadButtonMax+1)
+	for _, btn := range f.StandardButtons {
+		if btn >= 0 && btn <= ebiten.StandardGamepadButtonMax {
+			pressed[btn] = true
+		}
+	}
+	b = appendButtonOps(b, opStandardGamepadButtonDown, opStandardGamepadButtonUp, id, g.standardButtons, pressed)
+	b = appendAxisOps(b, opStandardGamepadAxis, id, g.standardAxes, f.StandardAxes)
+
+	return b
+}
+
+// appendButtonOps appends operations that change the state from current to next, and updates current.
+// prefix is written between the opcode and the button, such as a gamepad ID.
+func appendButtonOps(b []byte, down, up byte, prefix []byte, current, next []bool) []byte {
+	for i := range current {
+		if current[i] == next[i] {
+			continue
+		}
+		current[i] = next[i]
+
+		if next[i] {
+			b = append(b, down)
+		} else {
+			b = append(b, up)
+		}
+		b = append(b, prefix...)
+		b = binary.AppendUvarint(b, uint64(i))
+	}
+	return b
+}
+
+// appendAxisOps appends operations that change the quantized axis values from current to next, and updates current.
+func appendAxisOps(b []byte, op byte, prefix []byte, current []int, next []float64) []byte {
+	for i := range current {
+		v := 0
+		if i < len(next) {
+			v = quantizeAxis(next[i])
+		}
+		if current[i] == v {
+			continue
+		}
+		current[i] = v
+
+		b = append(b, op)
+		b = append(b, prefix...)
+		b = binary.AppendUvarint(b, uint64(i))
+		b = binary.AppendVarint(b, int64(v))
+	}
+	return b
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendGamepadProfile(b []byte, p GamepadProfile) []byte {
+	b = appendString(b, p.Name)
+	b = appendString(b, p.SDLID)
+	b = binary.AppendUvarint(b, uint64(max(p.ButtonCount, 0)))
+	b = binary.AppendUvarint(b, uint64(max(p.AxisCount, 0)))
+	var flags byte
+	if p.StandardLayout {
+		flags |= 1
+	}
+	return append(b, flags)
+}
+
+// RecordingDecoder reads frames in the binary recording format from an io.Reader.
+type RecordingDecoder struct {
+	r      *bufio.Reader
+	header RecordingHeader
+	state  *recordingState
+	gap    int
+	ended  bool
+	err    error
+}
+
+// NewRecordingDecoder reads the header from r and returns a decoder to read frames after it.
+func NewRecordingDecoder(r io.Reader) (*RecordingDecoder, error) {
+	d := &RecordingDecoder{
+		r: bufio.NewReader(r),
+	}
+
+	magic := make([]byte, len(recordingMagic))
+	if _, err := io.ReadFull(d.r, magic); err != nil {
+		return nil, fmt.Errorf("nyuuryoku: reading recording header: %w", err)
+	}
+	if string(magic) != recordingMagic {
+		return nil, errors.New("nyuuryoku: not a recording")
+	}
+
+	h := RecordingHeader{}
+	var err error
+	if h.Version, err = d.readInt(); err != nil {
+		return nil, err
+	}
+	if h.Version < 1 || h.Version > RecordingFormatVersion {
+		return nil, fmt.Errorf("nyuuryoku: unsupported recording format version: %d", h.Version)
+	}
+	if h.TPS, err = d.readInt(); err != nil {
+		return nil, err
+	}
+	if h.ScreenWidth, err = d.readInt(); err != nil {
+		return nil, err
+	}
+	if h.ScreenHeight, err = d.readInt(); err != nil {
+		return nil, err
+	}
+	n, err := d.readInt()
+	if err != nil {
+		return nil, err
+	}
+	for range n {
+		p, err := d.readGamepadProfile()
+		if err != nil {
+			return nil, err
+		}
+		h.Gamepads = append(h.Gamepads, p)
+	}
+
+	d.header = h
+	d.state = newRecordingState(h.Gamepads)
+	d.gap = -1
+
+	return d, nil
+}
+
+// Header returns the header of the recording.
+func (d *RecordingDecoder) Header() RecordingHeader {
+	return d.header
+}
+
+// Decode reads the next frame.
+// Decode returns io.EOF after the last frame.
+func (d *RecordingDecoder) Decode() (InputFrame, error) {
+	if d.err != nil {
+		return InputFrame{}, d.err
+	}
+
+	f, err := d.decode()
+	if err != nil {
+		if err == io.ErrUnexpectedEOF || (err == io.EOF && !d.ended) {
+			err = fmt.Errorf("nyuuryoku: truncated recording: %w", io.ErrUnexpectedEOF)
+		}
+		d.err = err
+		return InputFrame{}, err
+	}
+	return f, nil
+}
+
+func (d *RecordingDecoder) decode() (InputFrame, error) {
+	if d.gap < 0 {
+		if d.ended {
+			return InputFrame{}, io.EOF
+		}
+		gap, err := d.readInt()
+		if err != nil {
+			return InputFrame{}, err
+		}
+		d.gap = gap
+	}
+
+	if d.gap > 0 {
+		d.gap--
+		return d.frame(), nil
+	}
+
+	f, err := d.readOps()
+	if err != nil {
+		return InputFrame{}, err
+	}
+	d.gap = -1
+
+	if d.ended {
+		// The last record carries only the trailing gap.
+		return d.decode()
+	}
+	return f, nil
+}
+
+// readOps applies operations until the end of the record, and returns the new frame.
+func (d *RecordingDecoder) readOps() (InputFrame, error) {
+	s := d.state
+	var chars []rune
+	var wheelX, wheelY float64
+
+	for {
+		op, err := d.r.ReadByte()
+		if err != nil {
+			return InputFrame{}, err
+		}
+
+		switch op {
+		case opEndFrame, opEndStream:
+			d.ended = op == opEndStream
+			f := d.frame()
+			f.InputChars = chars
+			f.WheelX, f.WheelY = wheelX, wheelY
+			return f, nil
+
+		case opDefineKey:
+			name, err := d.readString()
+			if err != nil {
+				return InputFrame{}, err
+			}
+			var k ebiten.Key
+			if err := k.UnmarshalText([]byte(name)); err != nil {
+				return InputFrame{}, fmt.Errorf("nyuuryoku: unknown key in recording: %q", name)
+			}
+			s.keyTable = append(s.keyTable, k)
+
+		case opDefineGamepad:
+			p, err := d.readGamepadProfile()
+			if err != nil {
+				return InputFrame{}, err
+			}
+			s.profiles = append(s.profiles, p)
+
+		case opKeyDown, opKeyUp:
+			idx, err := d.readIndex(len(s.keyTable))
+			if err != nil {
+				return InputFrame{}, err
+			}
+			s.keys[s.keyTable[idx]] = op == opKeyDown
+
+		case opInputChars:
+			n, err := d.readInt()
+			if err != nil {
+				return InputFrame{}, err
+			}
+			for range n {
+				r, err := d.readInt()
+				if err != nil {
+					return InputFrame{}, err
+				}
+				chars = append(chars, rune(r))
+			}
+
+		case opCursor:
+			dx, err := binary.ReadVarint(d.r)
+			if err != nil {
+				return InputFrame{}, err
+			}
+			dy, err := binary.ReadVarint(d.r)
+			if err != nil {
+				return InputFrame{}, err
+			}
+			s.cursorX += int(dx)
+			s.cursorY += int(dy)
+
+		case opWheel:
+			x, err := binary.ReadVarint(d.r)
+			if err != nil {
+				return InputFrame{}, err
+			}
+			y, err := binary.ReadVarint(d.r)
+			if err != nil {
+				return InputFrame{}, err
+			}
+			wheelX, wheelY = dequantize(int(x), wheelQuantum), dequantize(int(y), wheelQuantum)
+
+		case opMouseButtonDown, opMouseButtonUp:
+			idx, err := d.readIndex(len(s.mouseButtons))
+			if err != nil {
+				return InputFrame{}, err
+			}
+			s.mouseButtons[idx] = op == opMouseButtonDown
+
+		case opGamepadConnect:
+			id, err := d.readInt()
+			if err != nil {
+				return InputFrame{}, err
+			}
+			idx, err := d.readIndex(len(s.profiles))
+			if err != nil {
+				return InputFrame{}, err
+			}
+			s.gamepads[ebiten.GamepadID(id)] = newRecordingGamepadState(idx, s.profiles[idx])
+
+		case opGamepadDisconnect:
+			id, err := d.readInt()
+			if err != nil {
+				return InputFrame{}, err
+			}
+			delete(s.gamepads, ebiten.GamepadID(id))
+
+		case opGamepadButtonDown, opGamepadButtonUp:
+			g, err := d.readGamepad()
+			if err != nil {
+				return InputFrame{}, err
+			}
+			idx, err := d.readIndex(len(g.buttons))
+			if err != nil {
+				return InputFrame{}, err
+			}
+			g.buttons[idx] = op == opGamepadButtonDown
+
+		case opStandardGamepadButtonDown, opStandardGamepadButtonUp:
+			g, err := d.readGamepad()
+			if err != nil {
+				return InputFrame{}, err
+			}
+			idx, err := d.readIndex(len(g.standardButtons))
+			if err != nil {
+				return InputFrame{}, err
+			}
+			g.standardButtons[idx] = op == opStandardGamepadButtonDown
+
+		case opGamepadAxis, opStandardGamepadAxis:
+			g, err := d.readGamepad()
+			if err != nil {
+				return InputFrame{}, err
+			}
+			axes := g.axes
+			if op == opStandardGamepadAxis {
+				axes = g.standardAxes
+			}
+			idx, err := d.readIndex(len(axes))
+			if err != nil {
+				return InputFrame{}, err
+			}
+			v, err := binary.ReadVarint(d.r)
+			if err != nil {
+				return InputFrame{}, err
+			}
+			axes[idx] = int(v)
+
+		default:
+			return InputFrame{}, fmt.Errorf("nyuuryoku: unknown opcode in recording: %d", op)
+		}
+	}
+}
+
+// frame returns the frame of the current state without input chars and wheel movement.
+func (d *RecordingDecoder) frame() InputFrame {
+	s := d.state
+	f := InputFrame{
+		CursorX: s.cursorX,
+		CursorY: s.cursorY,
+	}
+
+	for k, pressed := range s.keys {
+		if pressed {
+			f.Keys = append(f.Keys, ebiten.Key(k))
+		}
+	}
+	for b, pressed := range s.mouseButtons {
+		if pressed {
+			f.MouseButtons = append(f.MouseButtons, ebiten.MouseButton(b))
+		}
+	}
+
+	ids := make([]ebiten.GamepadID, 0, len(s.gamepads))
+	for id := range s.gamepads {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		g := s.gamepads[id]
+		gf := GamepadFrame{
+			ID:      id,
+			Profile: s.profiles[g.profileIndex],
+		}
+		for b, pressed := range g.buttons {
+			if pressed {
+				gf.Buttons = append(gf.Buttons, ebiten.GamepadButton(b))
+			}
+		}
+		if len(g.axes) > 0 {
+			gf.Axes = make([]float64, len(g.axes))
+			for a, v := range g.axes {
+				gf.Axes[a] = dequantize(v, axisQuantum)
+			}
+		}
+		if gf.Profile.StandardLayout {
+			for b, pressed := range g.standardButtons {
+				if pressed {
+					gf.StandardButtons = append(gf.StandardButtons, ebiten.StandardGamepadButton(b))
+				}
+			}
+			gf.StandardAxes = make([]float64, len(g.standardAxes))
+			for a, v := range g.standardAxes {
+				gf.StandardAxes[a] = dequantize(v, axisQuantum)
+			}
+		}
+		f.Gamepads = append(f.Gamepads, gf)
+	}
+
+	return f
+}
+
+func (d *RecordingDecoder) readInt() (int, error) {
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return 0, err
+	}
+	if v > math.MaxInt32 {
+		return 0, fmt.Errorf("nyuuryoku: value out of range in recording: %d", v)
+	}
+	return int(v), nil
+}
+
+// readIndex reads an index and checks that it is less than n.
+func (d *RecordingDecoder) readIndex(n int) (int, error) {
+	idx, err := d.readInt()
+	if err != nil {
+		return 0, err
+	}
+	if idx >= n {
+		return 0, fmt.Errorf("nyuuryoku: index out of range in recording: %d", idx)
+	}
+	return idx, nil
+}
+
+func (d *RecordingDecoder) readString() (string, error) {
+	n, err := d.readInt()
+	if err != nil {
+		return "", err
+	}
+	if n > maxRecordingStringLength {
+		return "", fmt.Errorf("nyuuryoku: string too long in recording: %d bytes", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d *RecordingDecoder) readGamepadProfile() (GamepadProfile, error) {
+	p := GamepadProfile{}
+	var err error
+	if p.Name, err = d.readString(); err != nil {
+		return p, err
+	}
+	if p.SDLID, err = d.readString(); err != nil {
+		return p, err
+	}
+	if p.ButtonCount, err = d.readInt(); err != nil {
+		return p, err
+	}
+	if p.ButtonCount > maxRecordingButtonCount {
+		return p, fmt.Errorf("nyuuryoku: too many gamepad buttons in recording: %d", p.ButtonCount)
+	}
+	if p.AxisCount, err = d.readInt(); err != nil {
+		return p, err
+	}
+	if p.AxisCount > maxRecordingAxisCount {
+		return p, fmt.Errorf("nyuuryoku: too many gamepad axes in recording: %d", p.AxisCount)
+	}
+	flags, err := d.r.ReadByte()
+	if err != nil {
+		return p, err
+	}
+	p.StandardLayout = flags&1 != 0
+	return p, nil
+}
+
+func (d *RecordingDecoder) readGamepad() (*recordingGamepadState, error) {
+	id, err := d.readInt()
+	if err != nil {
+		return nil, err
+	}
+	g, ok := d.state.gamepads[ebiten.GamepadID(id)]
+	if !ok {
+		return nil, fmt.Errorf("nyuuryoku: gamepad %d is not connected in recording", id)
+	}
+	return g, nil
+}
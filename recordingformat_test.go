@@ -0,0 +1,278 @@
+package nyuuryoku_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/noppikinatta/nyuuryoku"
+)
+
+var testRecordingHeader = nyuuryoku.RecordingHeader{
+	Version:      nyuuryoku.RecordingFormatVersion,
+	TPS:          60,
+	ScreenWidth:  640,
+	ScreenHeight: 480,
+	Gamepads:     []nyuuryoku.GamepadProfile{nyuuryoku.StandardGamepadProfile},
+}
+
+// testRecordingFrames returns frames in the same shape as Recorder records.
+func testRecordingFrames() []nyuuryoku.InputFrame {
+	pad := nyuuryoku.GamepadProfile{
+		Name:        "Arcade Stick",
+		SDLID:       "0123456789abcdef0123456789abcdef",
+		ButtonCount: 8,
+		AxisCount:   2,
+	}
+	standardAxes := make([]float64, ebiten.StandardGamepadAxisMax+1)
+	standardAxes[ebiten.StandardGamepadAxisLeftStickHorizontal] = -0.5
+
+	return []nyuuryoku.InputFrame{
+		{CursorX: 10, CursorY: 20},
+		{CursorX: 10, CursorY: 20},
+		{Keys: []ebiten.Key{ebiten.KeyA, ebiten.KeyShiftLeft}, CursorX: 12, CursorY: 18},
+		{Keys: []ebiten.Key{ebiten.KeyA}, InputChars: []rune("añ"), CursorX: 12, CursorY: 18, WheelY: -1.5},
+		{CursorX: 12, CursorY: 18, MouseButtons: []ebiten.MouseButton{ebiten.MouseButtonLeft}},
+		{
+			CursorX: 12, CursorY: 18,
+			Gamepads: []nyuuryoku.GamepadFrame{
+				{
+					ID:              0,
+					Profile:         nyuuryoku.StandardGamepadProfile,
+					Axes:            make([]float64, nyuuryoku.StandardGamepadProfile.AxisCount),
+					StandardButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightBottom},
+					StandardAxes:    standardAxes,
+				},
+				{
+					ID:      3,
+					Profile: pad,
+					Buttons: []ebiten.GamepadButton{1, 7},
+					Axes:    []float64{0.25, -1},
+				},
+			},
+		},
+		{CursorX: 12, CursorY: 18},
+		{CursorX: 12, CursorY: 18},
+		{CursorX: 12, CursorY: 18},
+	}
+}
+
+// normalizeFrames replaces empty slices with nil, so that frames can be compared regardless of how they were built.
+func normalizeFrames(frames []nyuuryoku.InputFrame) []nyuuryoku.InputFrame {
+	frames = append([]nyuuryoku.InputFrame(nil), frames...)
+	for i := range frames {
+		f := &frames[i]
+		if len(f.Keys) == 0 {
+			f.Keys = nil
+		}
+		if len(f.InputChars) == 0 {
+			f.InputChars = nil
+		}
+		if len(f.MouseButtons) == 0 {
+			f.MouseButtons = nil
+		}
+		if len(f.Gamepads) == 0 {
+			f.Gamepads = nil
+		}
+		for j := range f.Gamepads {
+			g := &f.Gamepads[j]
+			if len(g.Buttons) == 0 {
+				g.Buttons = nil
+			}
+			if len(g.Axes) == 0 {
+				g.Axes = nil
+			}
+			if len(g.StandardButtons) == 0 {
+				g.StandardButtons = nil
+			}
+			if len(g.StandardAxes) == 0 {
+				g.StandardAxes = nil
+			}
+		}
+	}
+	return frames
+}
+
+func writeTestRecording(t *testing.T, frames []nyuuryoku.InputFrame) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := nyuuryoku.WriteRecording(&buf, testRecordingHeader, frames); err != nil {
+		t.Fatalf("WriteRecording: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames []nyuuryoku.InputFrame
+	}{
+		{name: "empty", frames: nil},
+		{name: "single", frames: []nyuuryoku.InputFrame{{CursorX: -5, CursorY: 7}}},
+		{name: "idle", frames: make([]nyuuryoku.InputFrame, 100)},
+		{name: "mixed", frames: testRecordingFrames()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := writeTestRecording(t, tt.frames)
+
+			header, frames, err := nyuuryoku.ReadRecording(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ReadRecording: %v", err)
+			}
+			if !reflect.DeepEqual(header, testRecordingHeader) {
+				t.Errorf("header: got %+v, want %+v", header, testRecordingHeader)
+			}
+			if got, want := normalizeFrames(frames), normalizeFrames(tt.frames); !reflect.DeepEqual(got, want) {
+				t.Errorf("frames: got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestRecordingTruncated(t *testing.T) {
+	data := writeTestRecording(t, testRecordingFrames())
+
+	// Every proper prefix of a recording, including a partial header, is an error.
+	for n := range len(data) {
+		_, _, err := nyuuryoku.ReadRecording(bytes.NewReader(data[:n]))
+		if err == nil {
+			t.Fatalf("ReadRecording with %d of %d bytes: got no error", n, len(data))
+		}
+	}
+
+	// Once the header is read, the decoder reports truncation as io.ErrUnexpectedEOF.
+	d, err := nyuuryoku.NewRecordingDecoder(bytes.NewReader(data[:len(data)-1]))
+	if err != nil {
+		t.Fatalf("NewRecordingDecoder: %v", err)
+	}
+	for {
+		_, err := d.Decode()
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Decode: got %v, want io.ErrUnexpectedEOF", err)
+		}
+		break
+	}
+}
+
+func TestRecordingVersion(t *testing.T) {
+	data := writeTestRecording(t, testRecordingFrames())
+
+	// The version follows the 4-byte magic, as a one-byte uvarint for small versions.
+	tests := []struct {
+		name    string
+		version byte
+	}{
+		{name: "zero", version: 0},
+		{name: "newer", version: nyuuryoku.RecordingFormatVersion + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := bytes.Clone(data)
+			d[4] = tt.version
+			_, _, err := nyuuryoku.ReadRecording(bytes.NewReader(d))
+			if err == nil || !strings.Contains(err.Error(), "unsupported recording format version") {
+				t.Errorf("ReadRecording: got %v, want an unsupported version error", err)
+			}
+		})
+	}
+
+	if _, _, err := nyuuryoku.ReadRecording(strings.NewReader("NOPE\x01")); err == nil {
+		t.Error("ReadRecording without the magic: got no error")
+	}
+}
+
+// recordingV1 is a recording written by format version 1. It must stay readable by later versions.
+const recordingV1 = "NYRC\x01<\xc0\x02\xf0\x01\x00\x00\a\x06\b\x00\x00\x02\x05Space\x04\x00\x00\x01\x05\x00\x00\x00\x01"
+
+func TestRecordingVersion1(t *testing.T) {
+	wantHeader := nyuuryoku.RecordingHeader{Version: 1, TPS: 60, ScreenWidth: 320, ScreenHeight: 240}
+	space := []ebiten.Key{ebiten.KeySpace}
+	wantFrames := []nyuuryoku.InputFrame{
+		{CursorX: 3, CursorY: 4},
+		{Keys: space, CursorX: 3, CursorY: 4},
+		{Keys: space, CursorX: 3, CursorY: 4},
+		{CursorX: 3, CursorY: 4},
+	}
+
+	header, frames, err := nyuuryoku.ReadRecording(strings.NewReader(recordingV1))
+	if err != nil {
+		t.Fatalf("ReadRecording: %v", err)
+	}
+	if !reflect.DeepEqual(header, wantHeader) {
+		t.Errorf("header: got %+v, want %+v", header, wantHeader)
+	}
+	if got, want := normalizeFrames(frames), normalizeFrames(wantFrames); !reflect.DeepEqual(got, want) {
+		t.Errorf("frames: got %+v, want %+v", got, want)
+	}
+
+	if nyuuryoku.RecordingFormatVersion == 1 {
+		var buf bytes.Buffer
+		if err := nyuuryoku.WriteRecording(&buf, wantHeader, wantFrames); err != nil {
+			t.Fatalf("WriteRecording: %v", err)
+		}
+		if got := buf.String(); got != recordingV1 {
+			t.Errorf("WriteRecording: got %q, want %q", got, recordingV1)
+		}
+	}
+}
+
+// testRecordingBytes returns a recording with one gamepad descriptor followed by ops as the only record.
+// The name of the gamepad is declared as nameLen bytes long, and its bytes are written only if nameLen is small.
+func testRecordingBytes(nameLen, buttons, axes uint64, ops ...byte) []byte {
+	b := []byte("NYRC")
+	for _, v := range []uint64{1, 60, 640, 480, 1, nameLen} {
+		b = binary.AppendUvarint(b, v)
+	}
+	if nameLen <= 16 {
+		b = append(b, bytes.Repeat([]byte("x"), int(nameLen))...)
+	}
+	b = binary.AppendUvarint(b, 0) // SDL ID
+	b = binary.AppendUvarint(b, buttons)
+	b = binary.AppendUvarint(b, axes)
+	b = append(b, 0) // flags
+	b = append(b, 0) // gap
+	return append(b, ops...)
+}
+
+func TestRecordingCorrupt(t *testing.T) {
+	const (
+		opEndStream      = 1
+		opDefineKey      = 2
+		opGamepadConnect = 11
+	)
+	connect := []byte{opGamepadConnect, 0, 0, opEndStream}
+	longKey := binary.AppendUvarint([]byte{opDefineKey}, math.MaxInt32)
+
+	// The valid recording makes sure that the corrupt ones differ only in the tested field.
+	if _, _, err := nyuuryoku.ReadRecording(bytes.NewReader(testRecordingBytes(3, 4, 2, connect...))); err != nil {
+		t.Fatalf("ReadRecording of the valid recording: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "too many axes", data: testRecordingBytes(3, 4, math.MaxInt32, connect...)},
+		{name: "too many buttons", data: testRecordingBytes(3, math.MaxInt32, 2, connect...)},
+		{name: "too long name", data: testRecordingBytes(math.MaxInt32, 4, 2, connect...)},
+		{name: "too long key name", data: testRecordingBytes(3, 4, 2, longKey...)},
+		{name: "out of range", data: testRecordingBytes(math.MaxInt32+1, 4, 2, connect...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := nyuuryoku.ReadRecording(bytes.NewReader(tt.data)); err == nil {
+				t.Error("ReadRecording: got no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package nyuuryoku
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// RepeatConfig is the schedule of the repeat of a held key or button, such as the repeat of a key of an OS.
+//
+// The first repeat happens Delay ticks after the press, and the next ones every Interval ticks.
+// With Acceleration between 0 and 1, the interval is multiplied by Acceleration after each repeat,
+// but does not get shorter than MinInterval, or 1 tick if MinInterval is 0.
+// A Delay of 0 or less is treated as Interval. An Interval of 0 or less disables repeats.
+type RepeatConfig struct {
+	Delay        int
+	Interval     int
+	Acceleration float64
+	MinInterval  int
+}
+
+// DefaultRepeatConfig is the default RepeatConfig of Repeater, a repeat every 4 ticks after 30 ticks.
+var DefaultRepeatConfig = RepeatConfig{
+	Delay:    30,
+	Interval: 4,
+}
+
+// IsRepeat reports whether a repeat happens at the tick when the input has been held for duration ticks.
+// The press itself, at the duration 1, is not a repeat.
+func (c RepeatConfig) IsRepeat(duration int) bool {
+	if c.Interval <= 0 {
+		return false
+	}
+	delay := c.Delay
+	if delay <= 0 {
+		delay = c.Interval
+	}
+	next := 1 + delay
+	if duration < next {
+		return false
+	}
+	if c.Acceleration <= 0 || c.Acceleration >= 1 {
+		return (duration-next)%c.Interval == 0
+	}
+
+	// The interval shortens only until it reaches minInterval, and the repeats after that come every minInterval ticks.
+	minInterval := max(c.MinInterval, 1)
+	interval := float64(c.Interval)
+	for next < duration {
+		step := int(math.Round(interval))
+		if step <= minInterval {
+			return (duration-next)%minInterval == 0
+		}
+		next += step
+		interval *= c.Acceleration
+	}
+	return next == duration
+}
+
+// Repeater reports repeats of held keys, mouse buttons and gamepad buttons by their press durations.
+// Each binding can have its own RepeatConfig. Axis bindings have no press durations and never repeat.
+//
+// Repeater has no state of its own, so it needs no update.
+type Repeater struct {
+	reader        bindingReader
+	defaultConfig RepeatConfig
+	configs       map[Binding]RepeatConfig
+}
+
+// NewRepeater returns a Repeater with DefaultRepeatConfig. Any of the wrappers may be nil.
+func NewRepeater(keyboard *Keyboard, mouse *Mouse, gamepad *Gamepad) *Repeater {
+	return &Repeater{
+		reader: bindingReader{
+			keyboard: keyboard,
+			mouse:    mouse,
+			gamepad:  gamepad,
+		},
+		defaultConfig: DefaultRepeatConfig,
+		configs:       make(map[Binding]RepeatConfig),
+	}
+}
+
+// SetGamepadIDs restricts the gamepads that gamepad bindings are read from, in the same way as ActionMap.SetGamepadIDs.
+func (r *Repeater) SetGamepadIDs(ids ...ebiten.GamepadID) {
+	r.reader.setGamepadIDs(ids)
+}
+
+// SetDefaultConfig sets the config of the bindings without their own configs.
+func (r *Repeater) SetDefaultConfig(config RepeatConfig) {
+	r.defaultConfig = config
+}
+
+// SetConfig sets the config of the binding.
+func (r *Repeater) SetConfig(binding Binding, config RepeatConfig) {
+	r.configs[binding] = config
+}
+
+// Config returns the config of the binding.
+func (r *Repeater) Config(binding Binding) RepeatConfig {
+	if c, ok := r.configs[binding]; ok {
+		return c
+	}
+	return r.defaultConfig
+}
+
+// IsRepeated reports whether the binding repeats at the current tick.
+func (r *Repeater) IsRepeated(binding Binding) bool {
+	return r.Config(binding).IsRepeat(r.reader.pressDuration(binding))
+}
+
+// IsTriggered reports whether the binding is just pressed or repeats at the current tick,
+// which is when a menu cursor or a caret should move.
+func (r *Repeater) IsTriggered(binding Binding) bool {
+	d := r.reader.pressDuration(binding)
+	return d == 1 || r.Config(binding).IsRepeat(d)
+}
@@ -0,0 +1,129 @@
+package nyuuryoku
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// CurveKind is the shape of a ResponseCurve.
+type CurveKind int
+
+const (
+	// CurveLinear returns the input as it is.
+	CurveLinear CurveKind = iota
+	// CurvePower returns the input to the power of Exponent.
+	// Exponents greater than 1 give finer control near the center.
+	CurvePower
+	// CurveS returns x^e / (x^e + (1-x)^e) where e is Exponent.
+	// Exponents greater than 1 give finer control near both the center and the edge.
+	CurveS
+	// CurvePiecewiseLinear interpolates Points linearly.
+	CurvePiecewiseLinear
+)
+
+// CurvePoint is a point of a piecewise-linear ResponseCurve.
+type CurvePoint struct {
+	X float64
+	Y float64
+}
+
+// ResponseCurve maps the magnitude of an analog input in the range [0, 1] to an output magnitude in the range [0, 1].
+// The sign of the input is kept, so a curve applies to both directions of an axis.
+//
+// ResponseCurve is plain data, so it can be tuned in data files. An Exponent of 0 is treated as 1.
+// Apply does not fail on invalid curves: invalid exponents are treated as 1, and unknown kinds as CurveLinear.
+// Points must be sorted by X. Below the first point, the curve goes linearly from (0, 0) to it,
+// and above the last point, the Y of the last point is used.
+// Apply always maps 0 to 0, so a centered stick stays centered.
+type ResponseCurve struct {
+	Kind     CurveKind
+	Exponent float64
+	Points   []CurvePoint
+}
+
+// Validate reports whether c is a valid curve.
+func (c ResponseCurve) Validate() error {
+	switch c.Kind {
+	case CurveLinear:
+	case CurvePower, CurveS:
+		if c.Exponent < 0 || math.IsNaN(c.Exponent) || math.IsInf(c.Exponent, 0) {
+			return fmt.Errorf("nyuuryoku: invalid curve exponent: %v", c.Exponent)
+		}
+	case CurvePiecewiseLinear:
+		if len(c.Points) == 0 {
+			return errors.New("nyuuryoku: piecewise-linear curve without points")
+		}
+		for i := 1; i < len(c.Points); i++ {
+			if c.Points[i].X <= c.Points[i-1].X {
+				return fmt.Errorf("nyuuryoku: curve points are not sorted by X at index %d", i)
+			}
+		}
+	default:
+		return fmt.Errorf("nyuuryoku: unknown curve kind: %d", c.Kind)
+	}
+	return nil
+}
+
+// Apply returns v mapped by c.
+func (c ResponseCurve) Apply(v float64) float64 {
+	a := min(math.Abs(v), 1)
+	if a == 0 {
+		return 0
+	}
+	var r float64
+	switch c.Kind {
+	case CurvePower:
+		r = math.Pow(a, c.exponent())
+	case CurveS:
+		e := c.exponent()
+		p, q := math.Pow(a, e), math.Pow(1-a, e)
+		r = p / (p + q)
+	case CurvePiecewiseLinear:
+		r = c.interpolate(a)
+	default:
+		r = a
+	}
+	return math.Copysign(max(0, min(1, r)), v)
+}
+
+// exponent returns the exponent of c, or 1 if it is 0 or invalid, which would make Apply return NaN or Inf.
+func (c ResponseCurve) exponent() float64 {
+	if c.Exponent <= 0 || math.IsNaN(c.Exponent) || math.IsInf(c.Exponent, 0) {
+		return 1
+	}
+	return c.Exponent
+}
+
+func (c ResponseCurve) interpolate(x float64) float64 {
+	ps := c.Points
+	if len(ps) == 0 {
+		return x
+	}
+	if x <= ps[0].X {
+		if ps[0].X <= 0 {
+			return ps[0].Y
+		}
+		return ps[0].Y * x / ps[0].X
+	}
+	for i := 1; i < len(ps); i++ {
+		if x <= ps[i].X {
+			p0, p1 := ps[i-1], ps[i]
+			return p0.Y + (p1.Y-p0.Y)*(x-p0.X)/(p1.X-p0.X)
+		}
+	}
+	return ps[len(ps)-1].Y
+}
+
+// applyLength applies c to the length of (x, y), keeping its direction.
+func (c ResponseCurve) applyLength(x, y float64) (float64, float64) {
+	if c.Kind == CurveLinear {
+		return x, y
+	}
+	l := math.Hypot(x, y)
+	if l == 0 {
+		return 0, 0
+	}
+	nl := c.Apply(l)
+	return x / l * nl, y / l * nl
+}
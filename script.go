@@ -0,0 +1,690 @@
+package nyuuryoku
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Input scripts
+//
+// An input script is a human-editable text form of a frame sequence that Player consumes.
+// Each line is a command prefixed with the frame number where it takes effect:
+//
+//	# Comments start with '#'.
+//	@10 press Space
+//	@12 release Space
+//	@20 mouse move 100 200
+//	@20 mouse press Left
+//	@21 mouse wheel 0 -1
+//	@25 pad 0 connect
+//	@25 pad 1 connect "My Pad" "030000005e0400008e02000010010000" 11 6 nonstandard
+//	@30 pad 0 stdbutton RightBottom down
+//	@31 pad 0 stdaxis LeftStickHorizontal 0.5
+//	@32 pad 1 button 3 up
+//	@33 pad 1 axis 2 -1
+//	@34 pad 1 disconnect
+//	@40 type "hello"
+//	@60 end
+//
+// Frames start from 0 and must not decrease from line to line.
+// Pressed keys and buttons, the cursor position, connected gamepads and axis values persist until they are changed.
+// Typed characters and wheel offsets are reported only in the frame of their command.
+// "pad N connect" without a profile connects a gamepad with StandardGamepadProfile.
+// "@N end" makes the script N frames long. Without it, the script ends at the frame of its last command.
+//
+// Names of keys, buttons and axes are the names of Ebitengine's constants without their type prefix.
+
+// ScriptError is an error in an input script.
+type ScriptError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("nyuuryoku: script:%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ParseScript reads an input script from r and returns its frames.
+// A syntax error is reported as a *ScriptError.
+func ParseScript(r io.Reader) ([]InputFrame, error) {
+	p := &scriptParser{}
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		p.line++
+		if err := p.parseLine(s.Text()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return p.frames, nil
+}
+
+type scriptToken struct {
+	text   string
+	column int
+}
+
+type scriptParser struct {
+	frames []InputFrame
+	line   int
+	ended  bool
+	tokens []scriptToken
+	pos    int
+}
+
+func (p *scriptParser) errorf(column int, format string, args ...any) error {
+	return &ScriptError{Line: p.line, Column: column, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *scriptParser) parseLine(line string) error {
+	tokens, err := p.tokenize(line)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	p.tokens = tokens
+	p.pos = 0
+
+	t := p.next()
+	if !strings.HasPrefix(t.text, "@") {
+		return p.errorf(t.column, "line must start with @frame")
+	}
+	frame, err := strconv.Atoi(t.text[1:])
+	if err != nil || frame < 0 {
+		return p.errorf(t.column, "invalid frame: %s", t.text)
+	}
+	if p.ended {
+		return p.errorf(t.column, "command after end")
+	}
+	if frame < len(p.frames)-1 {
+		return p.errorf(t.column, "frame %d is before the previous frame %d", frame, len(p.frames)-1)
+	}
+
+	cmd, err := p.expect("command")
+	if err != nil {
+		return err
+	}
+
+	if cmd.text == "end" {
+		if frame < len(p.frames) {
+			return p.errorf(t.column, "end at frame %d is not after the last command", frame)
+		}
+		p.extend(frame)
+		p.ended = true
+		return p.expectEnd()
+	}
+
+	p.extend(frame + 1)
+	f := &p.frames[frame]
+
+	switch cmd.text {
+	case "press", "release":
+		t, err := p.expect("key")
+		if err != nil {
+			return err
+		}
+		k, ok := parseKeyName(t.text)
+		if !ok {
+			return p.errorf(t.column, "unknown key: %s", t.text)
+		}
+		f.Keys = setSorted(f.Keys, k, cmd.text == "press")
+
+	case "type":
+		t, err := p.expect("quoted string")
+		if err != nil {
+			return err
+		}
+		s, err := strconv.Unquote(t.text)
+		if err != nil || !strings.HasPrefix(t.text, `"`) {
+			return p.errorf(t.column, "invalid quoted string: %s", t.text)
+		}
+		f.InputChars = append(f.InputChars, []rune(s)...)
+
+	case "mouse":
+		if err := p.parseMouse(f); err != nil {
+			return err
+		}
+
+	case "pad":
+		if err := p.parsePad(f); err != nil {
+			return err
+		}
+
+	default:
+		return p.errorf(cmd.column, "unknown command: %s", cmd.text)
+	}
+
+	return p.expectEnd()
+}
+
+func (p *scriptParser) parseMouse(f *InputFrame) error {
+	sub, err := p.expect("mouse command")
+	if err != nil {
+		return err
+	}
+
+	switch sub.text {
+	case "move":
+		x, err := p.expectInt("x")
+		if err != nil {
+			return err
+		}
+		y, err := p.expectInt("y")
+		if err != nil {
+			return err
+		}
+		f.CursorX, f.CursorY = x, y
+
+	case "press", "release":
+		t, err := p.expect("mouse button")
+		if err != nil {
+			return err
+		}
+		b, ok := parseMouseButtonName(t.text)
+		if !ok {
+			return p.errorf(t.column, "unknown mouse button: %s", t.text)
+		}
+		f.MouseButtons = setSorted(f.MouseButtons, b, sub.text == "press")
+
+	case "wheel":
+		x, err := p.expectFloat("x offset")
+		if err != nil {
+			return err
+		}
+		y, err := p.expectFloat("y offset")
+		if err != nil {
+			return err
+		}
+		f.WheelX += x
+		f.WheelY += y
+
+	default:
+		return p.errorf(sub.column, "unknown mouse command: %s", sub.text)
+	}
+
+	return nil
+}
+
+func (p *scriptParser) parsePad(f *InputFrame) error {
+	idToken := p.peek()
+	n, err := p.expectInt("gamepad ID")
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return p.errorf(idToken.column, "invalid gamepad ID: %d", n)
+	}
+	id := ebiten.GamepadID(n)
+
+	sub, err := p.expect("gamepad command")
+	if err != nil {
+		return err
+	}
+
+	switch sub.text {
+	case "connect":
+		profile := StandardGamepadProfile
+		if p.pos < len(p.tokens) {
+			if profile, err = p.parseGamepadProfile(); err != nil {
+				return err
+			}
+		}
+		f.connectGamepad(id, profile)
+		return nil
+
+	case "disconnect":
+		f.Gamepads = slices.DeleteFunc(f.Gamepads, func(g GamepadFrame) bool {
+			return g.ID == id
+		})
+		if len(f.Gamepads) == 0 {
+			f.Gamepads = nil
+		}
+		return nil
+	}
+
+	g, ok := f.Gamepad(id)
+	if !ok {
+		return p.errorf(idToken.column, "gamepad %d is not connected", id)
+	}
+
+	switch sub.text {
+	case "button":
+		t := p.peek()
+		b, err := p.expectInt("button")
+		if err != nil {
+			return err
+		}
+		if b < 0 || b >= g.Profile.ButtonCount || b > int(ebiten.GamepadButtonMax) {
+			return p.errorf(t.column, "button out of range: %d", b)
+		}
+		pressed, err := p.expectUpDown()
+		if err != nil {
+			return err
+		}
+		g.Buttons = setSorted(g.Buttons, ebiten.GamepadButton(b), pressed)
+
+	case "stdbutton":
+		if !g.Profile.StandardLayout {
+			return p.errorf(sub.column, "gamepad %d does not have the standard layout", id)
+		}
+		t, err := p.expect("standard button")
+		if err != nil {
+			return err
+		}
+		b, ok := parseStandardGamepadButtonName(t.text)
+		if !ok {
+			return p.errorf(t.column, "unknown standard button: %s", t.text)
+		}
+		pressed, err := p.expectUpDown()
+		if err != nil {
+			return err
+		}
+		g.StandardButtons = setSorted(g.StandardButtons, b, pressed)
+
+	case "axis":
+		t := p.peek()
+		a, err := p.expectInt("axis")
+		if err != nil {
+			return err
+		}
+		if a < 0 || a >= len(g.Axes) {
+			return p.errorf(t.column, "axis out of range: %d", a)
+		}
+		v, err := p.expectFloat("axis value")
+		if err != nil {
+			return err
+		}
+		g.Axes[a] = v
+
+	case "stdaxis":
+		if !g.Profile.StandardLayout {
+			return p.errorf(sub.column, "gamepad %d does not have the standard layout", id)
+		}
+		t, err := p.expect("standard axis")
+		if err != nil {
+			return err
+		}
+		a, ok := parseStandardGamepadAxisName(t.text)
+		if !ok {
+			return p.errorf(t.column, "unknown standard axis: %s", t.text)
+		}
+		v, err := p.expectFloat("axis value")
+		if err != nil {
+			return err
+		}
+		g.StandardAxes[a] = v
+
+	default:
+		return p.errorf(sub.column, "unknown gamepad command: %s", sub.text)
+	}
+
+	return nil
+}
+
+func (p *scriptParser) parseGamepadProfile() (GamepadProfile, error) {
+	profile := GamepadProfile{}
+
+	for _, s := range []*string{&profile.Name, &profile.SDLID} {
+		t, err := p.expect("quoted string")
+		if err != nil {
+			return profile, err
+		}
+		v, err := strconv.Unquote(t.text)
+		if err != nil || !strings.HasPrefix(t.text, `"`) {
+			return profile, p.errorf(t.column, "invalid quoted string: %s", t.text)
+		}
+		*s = v
+	}
+
+	var err error
+	if profile.ButtonCount, err = p.expectInt("button count"); err != nil {
+		return profile, err
+	}
+	if profile.AxisCount, err = p.expectInt("axis count"); err != nil {
+		return profile, err
+	}
+
+	t, err := p.expect("standard or nonstandard")
+	if err != nil {
+		return profile, err
+	}
+	switch t.text {
+	case "standard":
+		profile.StandardLayout = true
+	case "nonstandard":
+	default:
+		return profile, p.errorf(t.column, "expected standard or nonstandard, got %s", t.text)
+	}
+
+	return profile, nil
+}
+
+// extend appends frames until the script has n frames.
+// Each new frame keeps the persistent state of the previous frame.
+func (p *scriptParser) extend(n int) {
+	for len(p.frames) < n {
+		f := InputFrame{}
+		if len(p.frames) > 0 {
+			f = p.frames[len(p.frames)-1].nextFrame()
+		}
+		p.frames = append(p.frames, f)
+	}
+}
+
+func (p *scriptParser) tokenize(line string) ([]scriptToken, error) {
+	var tokens []scriptToken
+
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		if c == ' ' || c == '\t' || c == '\r' {
+			i++
+			continue
+		}
+		if c == '#' {
+			break
+		}
+
+		start := i
+		if c == '"' {
+			i++
+			for i < len(line) && line[i] != '"' {
+				if line[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(line) {
+				return nil, p.errorf(p.column(line, start), "unterminated quoted string")
+			}
+			i++
+		} else {
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' && line[i] != '\r' {
+				i++
+			}
+		}
+
+		tokens = append(tokens, scriptToken{text: line[start:i], column: p.column(line, start)})
+	}
+
+	return tokens, nil
+}
+
+// column returns the 1-based column in characters of the byte offset in line.
+func (p *scriptParser) column(line string, offset int) int {
+	return utf8.RuneCountInString(line[:offset]) + 1
+}
+
+func (p *scriptParser) next() scriptToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// peek returns the next token, or a token at the end of the line if there are no more tokens.
+func (p *scriptParser) peek() scriptToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	if len(p.tokens) == 0 {
+		return scriptToken{column: 1}
+	}
+	last := p.tokens[len(p.tokens)-1]
+	return scriptToken{column: last.column + utf8.RuneCountInString(last.text) + 1}
+}
+
+func (p *scriptParser) expect(what string) (scriptToken, error) {
+	if p.pos >= len(p.tokens) {
+		return scriptToken{}, p.errorf(p.peek().column, "expected %s", what)
+	}
+	return p.next(), nil
+}
+
+func (p *scriptParser) expectEnd() error {
+	if p.pos < len(p.tokens) {
+		t := p.peek()
+		return p.errorf(t.column, "unexpected %s", t.text)
+	}
+	return nil
+}
+
+func (p *scriptParser) expectInt(what string) (int, error) {
+	t, err := p.expect(what)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(t.text)
+	if err != nil {
+		return 0, p.errorf(t.column, "invalid %s: %s", what, t.text)
+	}
+	return v, nil
+}
+
+func (p *scriptParser) expectFloat(what string) (float64, error) {
+	t, err := p.expect(what)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return 0, p.errorf(t.column, "invalid %s: %s", what, t.text)
+	}
+	return v, nil
+}
+
+func (p *scriptParser) expectUpDown() (bool, error) {
+	t, err := p.expect("down or up")
+	if err != nil {
+		return false, err
+	}
+	switch t.text {
+	case "down":
+		return true, nil
+	case "up":
+		return false, nil
+	}
+	return false, p.errorf(t.column, "expected down or up, got %s", t.text)
+}
+
+// FormatScript writes frames to w as an input script.
+// ParseScript returns the same frames for the output.
+func FormatScript(w io.Writer, frames []InputFrame) error {
+	bw := bufio.NewWriter(w)
+	prev := InputFrame{}
+
+	for i := range frames {
+		f := &frames[i]
+		cmds := appendScriptCommands(nil, &prev, f)
+		for _, cmd := range cmds {
+			if _, err := fmt.Fprintf(bw, "@%d %s\n", i, cmd); err != nil {
+				return err
+			}
+		}
+		prev = *f
+	}
+
+	if len(frames) > 0 {
+		if _, err := fmt.Fprintf(bw, "@%d end\n", len(frames)); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+func appendScriptCommands(cmds []string, prev, f *InputFrame) []string {
+	diffSorted(prev.Keys, f.Keys, func(k ebiten.Key, pressed bool) {
+		cmds = append(cmds, pressRelease(pressed)+" "+keyName(k))
+	})
+
+	if len(f.InputChars) > 0 {
+		cmds = append(cmds, "type "+strconv.Quote(string(f.InputChars)))
+	}
+
+	if f.CursorX != prev.CursorX || f.CursorY != prev.CursorY {
+		cmds = append(cmds, fmt.Sprintf("mouse move %d %d", f.CursorX, f.CursorY))
+	}
+	diffSorted(prev.MouseButtons, f.MouseButtons, func(b ebiten.MouseButton, pressed bool) {
+		cmds = append(cmds, "mouse "+pressRelease(pressed)+" "+mouseButtonName(b))
+	})
+	if f.WheelX != 0 || f.WheelY != 0 {
+		cmds = append(cmds, "mouse wheel "+formatFloat(f.WheelX)+" "+formatFloat(f.WheelY))
+	}
+
+	for _, g := range prev.Gamepads {
+		if _, ok := f.Gamepad(g.ID); !ok {
+			cmds = append(cmds, fmt.Sprintf("pad %d disconnect", g.ID))
+		}
+	}
+
+	for _, g := range f.Gamepads {
+		pg, ok := prev.Gamepad(g.ID)
+		if !ok || pg.Profile != g.Profile {
+			cmds = append(cmds, fmt.Sprintf("pad %d connect %s %s %d %d %s",
+				g.ID, strconv.Quote(g.Profile.Name), strconv.Quote(g.Profile.SDLID),
+				g.Profile.ButtonCount, g.Profile.AxisCount, layoutName(g.Profile.StandardLayout)))
+			empty := newGamepadFrame(g.ID, g.Profile)
+			pg = &empty
+		}
+
+		prefix := fmt.Sprintf("pad %d ", g.ID)
+		diffSorted(pg.Buttons, g.Buttons, func(b ebiten.GamepadButton, pressed bool) {
+			cmds = append(cmds, prefix+fmt.Sprintf("button %d %s", b, upDown(pressed)))
+		})
+		for a, v := range g.Axes {
+			if a >= len(pg.Axes) || pg.Axes[a] != v {
+				cmds = append(cmds, prefix+fmt.Sprintf("axis %d %s", a, formatFloat(v)))
+			}
+		}
+
+		if !g.Profile.StandardLayout {
+			continue
+		}
+		diffSorted(pg.StandardButtons, g.StandardButtons, func(b ebiten.StandardGamepadButton, pressed bool) {
+			cmds = append(cmds, prefix+fmt.Sprintf("stdbutton %s %s", standardGamepadButtonName(b), upDown(pressed)))
+		})
+		for a, v := range g.StandardAxes {
+			if a >= len(pg.StandardAxes) || pg.StandardAxes[a] != v {
+				cmds = append(cmds, prefix+fmt.Sprintf("stdaxis %s %s", standardGamepadAxisName(ebiten.StandardGamepadAxis(a)), formatFloat(v)))
+			}
+		}
+	}
+
+	return cmds
+}
+
+func pressRelease(pressed bool) string {
+	if pressed {
+		return "press"
+	}
+	return "release"
+}
+
+func upDown(pressed bool) string {
+	if pressed {
+		return "down"
+	}
+	return "up"
+}
+
+func layoutName(standard bool) string {
+	if standard {
+		return "standard"
+	}
+	return "nonstandard"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// diffSorted calls fn for each value that is only in prev with false, and for each value that is only in next with true.
+// prev and next must be sorted.
+func diffSorted[T cmp.Ordered](prev, next []T, fn func(v T, pressed bool)) {
+	for _, v := range prev {
+		if _, ok := slices.BinarySearch(next, v); !ok {
+			fn(v, false)
+		}
+	}
+	for _, v := range next {
+		if _, ok := slices.BinarySearch(prev, v); !ok {
+			fn(v, true)
+		}
+	}
+}
+
+// setSorted adds v to or removes v from the sorted slice s.
+// An empty result is nil, as the wrappers' Append functions return for nil.
+func setSorted[T cmp.Ordered](s []T, v T, add bool) []T {
+	i, ok := slices.BinarySearch(s, v)
+	switch {
+	case add && !ok:
+		s = slices.Insert(s, i, v)
+	case !add && ok:
+		s = slices.Delete(s, i, i+1)
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+func newGamepadFrame(id ebiten.GamepadID, profile GamepadProfile) GamepadFrame {
+	g := GamepadFrame{
+		ID:      id,
+		Profile: profile,
+	}
+	if profile.AxisCount > 0 {
+		g.Axes = make([]float64, profile.AxisCount)
+	}
+	if profile.StandardLayout {
+		g.StandardAxes = make([]float64, ebiten.StandardGamepadAxisMax+1)
+	}
+	return g
+}
+
+// connectGamepad connects a gamepad to f, replacing the gamepad with the same ID.
+func (f *InputFrame) connectGamepad(id ebiten.GamepadID, profile GamepadProfile) {
+	g := newGamepadFrame(id, profile)
+	i, ok := slices.BinarySearchFunc(f.Gamepads, id, func(g GamepadFrame, id ebiten.GamepadID) int {
+		return cmp.Compare(g.ID, id)
+	})
+	if ok {
+		f.Gamepads[i] = g
+		return
+	}
+	f.Gamepads = slices.Insert(f.Gamepads, i, g)
+}
+
+// nextFrame returns a copy of f that shares no memory with f, without input chars and wheel offsets.
+func (f *InputFrame) nextFrame() InputFrame {
+	next := InputFrame{
+		Keys:         slices.Clone(f.Keys),
+		CursorX:      f.CursorX,
+		CursorY:      f.CursorY,
+		MouseButtons: slices.Clone(f.MouseButtons),
+	}
+	for _, g := range f.Gamepads {
+		g.Buttons = slices.Clone(g.Buttons)
+		g.Axes = slices.Clone(g.Axes)
+		g.StandardButtons = slices.Clone(g.StandardButtons)
+		g.StandardAxes = slices.Clone(g.StandardAxes)
+		next.Gamepads = append(next.Gamepads, g)
+	}
+	return next
+}
@@ -0,0 +1,192 @@
+package nyuuryoku_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/noppikinatta/nyuuryoku"
+)
+
+// documentedScript is the example in the documentation of input scripts.
+const documentedScript = `# Comments start with '#'.
+@10 press Space
+@12 release Space
+@20 mouse move 100 200
+@20 mouse press Left
+@21 mouse wheel 0 -1
+@25 pad 0 connect
+@25 pad 1 connect "My Pad" "030000005e0400008e02000010010000" 11 6 nonstandard
+@30 pad 0 stdbutton RightBottom down
+@31 pad 0 stdaxis LeftStickHorizontal 0.5
+@32 pad 1 button 3 up
+@33 pad 1 axis 2 -1
+@34 pad 1 disconnect
+@40 type "hello"
+@60 end
+`
+
+func parseTestScript(t *testing.T, script string) []nyuuryoku.InputFrame {
+	t.Helper()
+	frames, err := nyuuryoku.ParseScript(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("ParseScript: %v", err)
+	}
+	return frames
+}
+
+func TestParseScriptDocumentedExample(t *testing.T) {
+	frames := parseTestScript(t, documentedScript)
+	if got, want := len(frames), 60; got != want {
+		t.Fatalf("len(frames): got %d, want %d", got, want)
+	}
+
+	space := []ebiten.Key{ebiten.KeySpace}
+	for _, tt := range []struct {
+		frame int
+		keys  []ebiten.Key
+	}{
+		{9, nil},
+		{10, space},
+		{11, space},
+		{12, nil},
+	} {
+		if got := frames[tt.frame].Keys; len(got) != len(tt.keys) || len(got) > 0 && !reflect.DeepEqual(got, tt.keys) {
+			t.Errorf("frames[%d].Keys: got %v, want %v", tt.frame, got, tt.keys)
+		}
+	}
+
+	if f := frames[20]; f.CursorX != 100 || f.CursorY != 200 || !reflect.DeepEqual(f.MouseButtons, []ebiten.MouseButton{ebiten.MouseButtonLeft}) {
+		t.Errorf("frames[20]: got cursor (%d, %d) and buttons %v, want (100, 200) and [Left]", f.CursorX, f.CursorY, f.MouseButtons)
+	}
+	if got := frames[59].MouseButtons; !reflect.DeepEqual(got, []ebiten.MouseButton{ebiten.MouseButtonLeft}) {
+		t.Errorf("frames[59].MouseButtons: got %v, want the button to stay pressed", got)
+	}
+	if x, y := frames[21].WheelX, frames[21].WheelY; x != 0 || y != -1 {
+		t.Errorf("frames[21] wheel: got (%v, %v), want (0, -1)", x, y)
+	}
+	if x, y := frames[22].WheelX, frames[22].WheelY; x != 0 || y != 0 {
+		t.Errorf("frames[22] wheel: got (%v, %v), want (0, 0)", x, y)
+	}
+
+	if got := string(frames[40].InputChars); got != "hello" {
+		t.Errorf("frames[40].InputChars: got %q, want %q", got, "hello")
+	}
+	if got := frames[41].InputChars; len(got) != 0 {
+		t.Errorf("frames[41].InputChars: got %q, want none", string(got))
+	}
+
+	if _, ok := frames[24].Gamepad(0); ok {
+		t.Error("frames[24]: gamepad 0 is connected before its connect command")
+	}
+	pad0, ok := frames[31].Gamepad(0)
+	if !ok {
+		t.Fatal("frames[31]: gamepad 0 is not connected")
+	}
+	if !reflect.DeepEqual(pad0.Profile, nyuuryoku.StandardGamepadProfile) {
+		t.Errorf("gamepad 0 profile: got %+v, want StandardGamepadProfile", pad0.Profile)
+	}
+	if !reflect.DeepEqual(pad0.StandardButtons, []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightBottom}) {
+		t.Errorf("frames[31] gamepad 0 standard buttons: got %v, want [RightBottom]", pad0.StandardButtons)
+	}
+	if got := pad0.StandardAxes[ebiten.StandardGamepadAxisLeftStickHorizontal]; got != 0.5 {
+		t.Errorf("frames[31] gamepad 0 LeftStickHorizontal: got %v, want 0.5", got)
+	}
+
+	pad1, ok := frames[33].Gamepad(1)
+	if !ok {
+		t.Fatal("frames[33]: gamepad 1 is not connected")
+	}
+	wantProfile := nyuuryoku.GamepadProfile{
+		Name:        "My Pad",
+		SDLID:       "030000005e0400008e02000010010000",
+		ButtonCount: 11,
+		AxisCount:   6,
+	}
+	if !reflect.DeepEqual(pad1.Profile, wantProfile) {
+		t.Errorf("gamepad 1 profile: got %+v, want %+v", pad1.Profile, wantProfile)
+	}
+	if got := pad1.Axes[2]; got != -1 {
+		t.Errorf("frames[33] gamepad 1 axis 2: got %v, want -1", got)
+	}
+	if _, ok := frames[34].Gamepad(1); ok {
+		t.Error("frames[34]: gamepad 1 is still connected after its disconnect command")
+	}
+}
+
+func TestScriptRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{name: "empty", script: ""},
+		{name: "documented", script: documentedScript},
+		{name: "single frame", script: "@0 press A\n"},
+		{name: "same frame", script: "@3 press ShiftLeft\n@3 press A\n@3 type \"A\\\"\\n\"\n@4 release A\n"},
+		{name: "unicode", script: "@0 type \"こんにちは\"\n@1 mouse wheel 0.25 -1.5\n@9 end\n"},
+		{name: "reconnect", script: "@0 pad 2 connect\n@1 pad 2 disconnect\n@2 pad 2 connect \"Stick\" \"0\" 4 2 nonstandard\n@2 pad 2 axis 1 0.75\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames := parseTestScript(t, tt.script)
+
+			var buf bytes.Buffer
+			if err := nyuuryoku.FormatScript(&buf, frames); err != nil {
+				t.Fatalf("FormatScript: %v", err)
+			}
+			formatted := buf.String()
+
+			got := parseTestScript(t, formatted)
+			if !reflect.DeepEqual(normalizeFrames(got), normalizeFrames(frames)) {
+				t.Errorf("frames differ after a round trip through:\n%s", formatted)
+			}
+
+			buf.Reset()
+			if err := nyuuryoku.FormatScript(&buf, got); err != nil {
+				t.Fatalf("FormatScript: %v", err)
+			}
+			if buf.String() != formatted {
+				t.Errorf("FormatScript is not stable: got\n%s\nwant\n%s", buf.String(), formatted)
+			}
+		})
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		line   int
+		column int
+	}{
+		{name: "no frame", script: "press Space", line: 1, column: 1},
+		{name: "invalid frame", script: "@x press Space", line: 1, column: 1},
+		{name: "decreasing frame", script: "@1 press Space\n@0 release Space", line: 2, column: 1},
+		{name: "unknown command", script: "@1 jump", line: 1, column: 4},
+		{name: "unknown key", script: "# comment\n\n@1 press Spacebar", line: 3, column: 10},
+		{name: "missing key", script: "@1 press", line: 1, column: 10},
+		{name: "unterminated string", script: "@1 type \"abc", line: 1, column: 9},
+		{name: "invalid int", script: "@1 mouse move 1 x", line: 1, column: 17},
+		{name: "unknown mouse command", script: "@1 mouse click Left", line: 1, column: 10},
+		{name: "trailing token", script: "@1 press A extra", line: 1, column: 12},
+		{name: "trailing token after non-ASCII", script: "@1 type \"é\" x", line: 1, column: 13},
+		{name: "command after end", script: "@5 end\n@6 press A", line: 2, column: 1},
+		{name: "end before last command", script: "@5 press A\n@5 end", line: 2, column: 1},
+		{name: "invalid layout", script: "@0 pad 1 connect \"P\" \"0\" 4 2 other", line: 1, column: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := nyuuryoku.ParseScript(strings.NewReader(tt.script))
+			var se *nyuuryoku.ScriptError
+			if !errors.As(err, &se) {
+				t.Fatalf("ParseScript: got %v, want a *ScriptError", err)
+			}
+			if se.Line != tt.line || se.Column != tt.column {
+				t.Errorf("ParseScript: got %d:%d (%v), want %d:%d", se.Line, se.Column, err, tt.line, tt.column)
+			}
+		})
+	}
+}
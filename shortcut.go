@@ -0,0 +1,247 @@
+package nyuuryoku
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Modifiers is a set of modifier keys of a Shortcut.
+type Modifiers uint8
+
+const (
+	ModifierCtrl Modifiers = 1 << iota
+	ModifierShift
+	ModifierAlt
+	ModifierMeta
+)
+
+type modifierInfo struct {
+	modifier Modifiers
+	name     string
+	aliases  []string
+	keys     []ebiten.Key
+}
+
+// modifierInfos is in the order of the names of shortcuts.
+var modifierInfos = []modifierInfo{
+	{ModifierCtrl, "Ctrl", []string{"ctrl", "control"}, []ebiten.Key{ebiten.KeyControl, ebiten.KeyControlLeft, ebiten.KeyControlRight}},
+	{ModifierShift, "Shift", []string{"shift"}, []ebiten.Key{ebiten.KeyShift, ebiten.KeyShiftLeft, ebiten.KeyShiftRight}},
+	{ModifierAlt, "Alt", []string{"alt", "option"}, []ebiten.Key{ebiten.KeyAlt, ebiten.KeyAltLeft, ebiten.KeyAltRight}},
+	{ModifierMeta, "Meta", []string{"meta", "cmd", "command", "super"}, []ebiten.Key{ebiten.KeyMeta, ebiten.KeyMetaLeft, ebiten.KeyMetaRight}},
+}
+
+func isModifierKey(key ebiten.Key) bool {
+	for _, m := range modifierInfos {
+		if slices.Contains(m.keys, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// PressedModifiers returns the modifiers held on keyboard. The left and right keys of a modifier are equivalent.
+func PressedModifiers(keyboard *Keyboard) Modifiers {
+	var mods Modifiers
+	for _, m := range modifierInfos {
+		if slices.ContainsFunc(m.keys, keyboard.IsPressed) {
+			mods |= m.modifier
+		}
+	}
+	return mods
+}
+
+// Shortcut is a key with modifiers, such as Ctrl+Shift+Z.
+type Shortcut struct {
+	Modifiers Modifiers
+	Key       ebiten.Key
+}
+
+// ParseShortcut parses a shortcut such as "Ctrl+Shift+Z" or "Meta+S".
+//
+// Modifiers are Ctrl (or Control), Shift, Alt (or Option) and Meta (or Cmd, Command and Super), in any order and case.
+// The key is the name of an Ebitengine key with or without the "Key" prefix, and must not be a modifier.
+func ParseShortcut(s string) (Shortcut, error) {
+	parts := strings.Split(s, "+")
+	var sc Shortcut
+	for i, p := range parts {
+		p = strings.TrimSpace(p)
+		if i == len(parts)-1 {
+			key, ok := parseKeyName(p)
+			if !ok {
+				return Shortcut{}, fmt.Errorf("nyuuryoku: unknown key in shortcut: %q", s)
+			}
+			if isModifierKey(key) {
+				return Shortcut{}, fmt.Errorf("nyuuryoku: shortcut without a non-modifier key: %q", s)
+			}
+			sc.Key = key
+			break
+		}
+		m := slices.IndexFunc(modifierInfos, func(m modifierInfo) bool {
+			return slices.Contains(m.aliases, strings.ToLower(p))
+		})
+		if m < 0 {
+			return Shortcut{}, fmt.Errorf("nyuuryoku: unknown modifier in shortcut: %q", s)
+		}
+		if sc.Modifiers&modifierInfos[m].modifier != 0 {
+			return Shortcut{}, fmt.Errorf("nyuuryoku: duplicated modifier in shortcut: %q", s)
+		}
+		sc.Modifiers |= modifierInfos[m].modifier
+	}
+	return sc, nil
+}
+
+// String returns the canonical form of s, such as "Ctrl+Shift+Z", which ParseShortcut accepts.
+func (s Shortcut) String() string {
+	return s.format(keyName(s.Key))
+}
+
+// Format returns s for display, with the name of the key in the keyboard layout by Keyboard.Name, such as "Ctrl+Shift+Z".
+// Keys without a name in the layout use their Ebitengine names.
+func (s Shortcut) Format(keyboard *Keyboard) string {
+	name := keyboard.Name(s.Key)
+	switch {
+	case name == "":
+		name = keyName(s.Key)
+	case utf8.RuneCountInString(name) == 1:
+		name = strings.ToUpper(name)
+	}
+	return s.format(name)
+}
+
+func (s Shortcut) format(keyName string) string {
+	var b strings.Builder
+	for _, m := range modifierInfos {
+		if s.Modifiers&m.modifier != 0 {
+			b.WriteString(m.name)
+			b.WriteByte('+')
+		}
+	}
+	b.WriteString(keyName)
+	return b.String()
+}
+
+// IsPressed reports whether the key of s is held with exactly the modifiers of s.
+func (s Shortcut) IsPressed(keyboard *Keyboard) bool {
+	return keyboard.IsPressed(s.Key) && PressedModifiers(keyboard) == s.Modifiers
+}
+
+// IsJustPressed reports whether the key of s is just pressed with exactly the modifiers of s held,
+// so Ctrl+Z does not fire for Ctrl+Shift+Z.
+func (s Shortcut) IsJustPressed(keyboard *Keyboard) bool {
+	return keyboard.IsJustPressed(s.Key) && PressedModifiers(keyboard) == s.Modifiers
+}
+
+// ShortcutConflictError is returned when a shortcut is registered to a command while another command has it.
+type ShortcutConflictError struct {
+	Shortcut Shortcut
+	Command  string
+	Existing string
+}
+
+func (e *ShortcutConflictError) Error() string {
+	return fmt.Sprintf("nyuuryoku: shortcut %s for %q is already registered for %q", e.Shortcut, e.Command, e.Existing)
+}
+
+// ShortcutRegistry maps shortcuts to named commands. A command can have more than one shortcut,
+// but a shortcut belongs to at most one command.
+type ShortcutRegistry struct {
+	keyboard *Keyboard
+	entries  []shortcutEntry
+}
+
+type shortcutEntry struct {
+	command  string
+	shortcut Shortcut
+}
+
+func NewShortcutRegistry(keyboard *Keyboard) *ShortcutRegistry {
+	return &ShortcutRegistry{
+		keyboard: keyboard,
+	}
+}
+
+// Register adds a shortcut to the command.
+// If another command has the shortcut, Register returns a *ShortcutConflictError and does not add it.
+func (r *ShortcutRegistry) Register(command string, shortcut Shortcut) error {
+	for _, e := range r.entries {
+		if e.shortcut != shortcut {
+			continue
+		}
+		if e.command == command {
+			return nil
+		}
+		return &ShortcutConflictError{Shortcut: shortcut, Command: command, Existing: e.command}
+	}
+	r.entries = append(r.entries, shortcutEntry{command: command, shortcut: shortcut})
+	return nil
+}
+
+// RegisterString parses the shortcut by ParseShortcut and registers it to the command.
+func (r *ShortcutRegistry) RegisterString(command string, shortcut string) error {
+	s, err := ParseShortcut(shortcut)
+	if err != nil {
+		return err
+	}
+	return r.Register(command, s)
+}
+
+// Unregister removes all the shortcuts of the command.
+func (r *ShortcutRegistry) Unregister(command string) {
+	r.entries = slices.DeleteFunc(r.entries, func(e shortcutEntry) bool {
+		return e.command == command
+	})
+}
+
+// Command returns the command that has the shortcut.
+func (r *ShortcutRegistry) Command(shortcut Shortcut) (string, bool) {
+	for _, e := range r.entries {
+		if e.shortcut == shortcut {
+			return e.command, true
+		}
+	}
+	return "", false
+}
+
+// Shortcuts returns the shortcuts of the command in the order they were registered.
+func (r *ShortcutRegistry) Shortcuts(command string) []Shortcut {
+	var shortcuts []Shortcut
+	for _, e := range r.entries {
+		if e.command == command {
+			shortcuts = append(shortcuts, e.shortcut)
+		}
+	}
+	return shortcuts
+}
+
+// Format returns the shortcuts of the command for display by Shortcut.Format, separated by ", ".
+func (r *ShortcutRegistry) Format(command string) string {
+	var names []string
+	for _, s := range r.Shortcuts(command) {
+		names = append(names, s.Format(r.keyboard))
+	}
+	return strings.Join(names, ", ")
+}
+
+// IsJustPressed reports whether any shortcut of the command is just pressed.
+func (r *ShortcutRegistry) IsJustPressed(command string) bool {
+	for _, e := range r.entries {
+		if e.command == command && e.shortcut.IsJustPressed(r.keyboard) {
+			return true
+		}
+	}
+	return false
+}
+
+// JustPressed returns the command whose shortcut is just pressed at the current tick, if any.
+func (r *ShortcutRegistry) JustPressed() (string, bool) {
+	for _, e := range r.entries {
+		if e.shortcut.IsJustPressed(r.keyboard) {
+			return e.command, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,375 @@
+package nyuuryoku
+
+import (
+	"unicode"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// TextFilter reports whether the rune r can be inserted at the position pos of a text, in runes.
+type TextFilter func(r rune, pos int) bool
+
+// NumericFilter accepts only decimal digits.
+func NumericFilter(r rune, pos int) bool {
+	return r >= '0' && r <= '9'
+}
+
+// IdentifierFilter accepts letters, digits and underscores, except digits at the start.
+func IdentifierFilter(r rune, pos int) bool {
+	if r == '_' || unicode.IsLetter(r) {
+		return true
+	}
+	return pos > 0 && unicode.IsDigit(r)
+}
+
+// maxUndo is the maximum number of undo steps of TextInput.
+const maxUndo = 100
+
+type textEdit int
+
+const (
+	textEditNone textEdit = iota
+	textEditTyping
+	textEditOther
+)
+
+type textSnapshot struct {
+	text   []rune
+	caret  int
+	anchor int
+}
+
+// TextInput is a model of a single-line text field driven by a Keyboard. It does not render anything.
+//
+// TextInput supports typing by Keyboard.AppendInputChars, Backspace, Delete, the arrow keys, Home and End,
+// word navigation and deletion with Ctrl or Alt, selection with Shift, Ctrl+A to select all,
+// and undo by Ctrl+Z and redo by Ctrl+Shift+Z or Ctrl+Y. Meta works as Ctrl for the shortcuts.
+// Held keys repeat by a Repeater.
+//
+// Positions are in runes. Call Update once per tick while the field has focus.
+type TextInput struct {
+	keyboard *Keyboard
+	repeater *Repeater
+
+	text      []rune
+	caret     int
+	anchor    int
+	maxLength int
+	filter    TextFilter
+
+	undo     []textSnapshot
+	redo     []textSnapshot
+	lastEdit textEdit
+
+	tmpRunes []rune
+}
+
+func NewTextInput(keyboard *Keyboard) *TextInput {
+	return &TextInput{
+		keyboard: keyboard,
+		repeater: NewRepeater(keyboard, nil, nil),
+	}
+}
+
+// SetMaxLength sets the maximum number of runes. 0 means no limit.
+// The current text is not truncated.
+func (t *TextInput) SetMaxLength(n int) {
+	t.maxLength = n
+}
+
+// SetFilter sets the filter of inserted runes. nil accepts all runes except control characters.
+func (t *TextInput) SetFilter(filter TextFilter) {
+	t.filter = filter
+}
+
+// SetRepeatConfig sets the repeat of held keys. The default is DefaultRepeatConfig.
+func (t *TextInput) SetRepeatConfig(config RepeatConfig) {
+	t.repeater.SetDefaultConfig(config)
+}
+
+// Text returns the text.
+func (t *TextInput) Text() string {
+	return string(t.text)
+}
+
+// SetText replaces the text, moves the caret to the end and clears the undo history.
+func (t *TextInput) SetText(text string) {
+	t.text = []rune(text)
+	t.caret = len(t.text)
+	t.anchor = t.caret
+	t.undo = t.undo[:0]
+	t.redo = t.redo[:0]
+	t.lastEdit = textEditNone
+}
+
+// Caret returns the position of the caret.
+func (t *TextInput) Caret() int {
+	return t.caret
+}
+
+// SetCaret moves the caret and clears the selection.
+func (t *TextInput) SetCaret(pos int) {
+	t.Select(pos, pos)
+}
+
+// Selection returns the range of the selection. start equals end when nothing is selected.
+func (t *TextInput) Selection() (start, end int) {
+	return min(t.anchor, t.caret), max(t.anchor, t.caret)
+}
+
+// Select selects the range from anchor to caret. The caret is at caret after Select.
+func (t *TextInput) Select(anchor, caret int) {
+	t.anchor = t.clamp(anchor)
+	t.caret = t.clamp(caret)
+	t.lastEdit = textEditNone
+}
+
+// SelectAll selects the whole text.
+func (t *TextInput) SelectAll() {
+	t.Select(0, len(t.text))
+}
+
+// SelectedText returns the selected text.
+func (t *TextInput) SelectedText() string {
+	s, e := t.Selection()
+	return string(t.text[s:e])
+}
+
+// Insert replaces the selection with text, such as pasted text.
+// Runes rejected by the filter are skipped, and the text is truncated to the maximum length.
+// Insert reports whether the text changed.
+func (t *TextInput) Insert(text string) bool {
+	return t.insert([]rune(text), textEditOther)
+}
+
+// CanUndo reports whether there is a change to undo.
+func (t *TextInput) CanUndo() bool {
+	return len(t.undo) > 0
+}
+
+// CanRedo reports whether there is an undone change to redo.
+func (t *TextInput) CanRedo() bool {
+	return len(t.redo) > 0
+}
+
+// Undo reverts the last change. Consecutive typing is undone at once.
+func (t *TextInput) Undo() bool {
+	if len(t.undo) == 0 {
+		return false
+	}
+	t.redo = append(t.redo, t.snapshot())
+	t.restore(t.undo[len(t.undo)-1])
+	t.undo = t.undo[:len(t.undo)-1]
+	return true
+}
+
+// Redo reapplies the last undone change.
+func (t *TextInput) Redo() bool {
+	if len(t.redo) == 0 {
+		return false
+	}
+	t.undo = append(t.undo, t.snapshot())
+	t.restore(t.redo[len(t.redo)-1])
+	t.redo = t.redo[:len(t.redo)-1]
+	return true
+}
+
+// Update handles the input of the current tick and reports whether the text changed.
+func (t *TextInput) Update() bool {
+	mods := PressedModifiers(t.keyboard)
+	shift := mods&ModifierShift != 0
+	command := mods&(ModifierCtrl|ModifierMeta) != 0
+	word := mods&(ModifierCtrl|ModifierAlt) != 0
+
+	changed := false
+
+	switch {
+	case command && t.keyboard.IsJustPressed(ebiten.KeyA):
+		t.SelectAll()
+	case command && (shift && t.isTriggered(ebiten.KeyZ) || !shift && t.isTriggered(ebiten.KeyY)):
+		changed = t.Redo()
+	case command && t.isTriggered(ebiten.KeyZ):
+		changed = t.Undo()
+	case t.isTriggered(ebiten.KeyBackspace):
+		changed = t.deleteBackward(word)
+	case t.isTriggered(ebiten.KeyDelete):
+		changed = t.deleteForward(word)
+	case t.isTriggered(ebiten.KeyArrowLeft):
+		t.moveHorizontally(-1, word, shift)
+	case t.isTriggered(ebiten.KeyArrowRight):
+		t.moveHorizontally(1, word, shift)
+	case t.keyboard.IsJustPressed(ebiten.KeyHome):
+		t.moveTo(0, shift)
+	case t.keyboard.IsJustPressed(ebiten.KeyEnd):
+		t.moveTo(len(t.text), shift)
+	}
+
+	t.tmpRunes = t.keyboard.AppendInputChars(t.tmpRunes[:0])
+	if len(t.tmpRunes) > 0 && t.insert(t.tmpRunes, textEditTyping) {
+		changed = true
+	}
+	return changed
+}
+
+func (t *TextInput) isTriggered(key ebiten.Key) bool {
+	return t.repeater.IsTriggered(KeyBinding(key))
+}
+
+func (t *TextInput) clamp(pos int) int {
+	return max(0, min(len(t.text), pos))
+}
+
+func (t *TextInput) snapshot() textSnapshot {
+	return textSnapshot{
+		text:   append([]rune(nil), t.text...),
+		caret:  t.caret,
+		anchor: t.anchor,
+	}
+}
+
+func (t *TextInput) restore(s textSnapshot) {
+	t.text = s.text
+	t.caret = s.caret
+	t.anchor = s.anchor
+	t.lastEdit = textEditNone
+}
+
+// beginEdit records the state before an edit for undo. Consecutive typing shares one undo step.
+func (t *TextInput) beginEdit(edit textEdit) {
+	if edit != textEditTyping || t.lastEdit != textEditTyping {
+		t.undo = append(t.undo, t.snapshot())
+		if len(t.undo) > maxUndo {
+			t.undo = t.undo[1:]
+		}
+	}
+	t.redo = t.redo[:0]
+	t.lastEdit = edit
+}
+
+func (t *TextInput) insert(runes []rune, edit textEdit) bool {
+	s, e := t.Selection()
+
+	var accepted []rune
+	for _, r := range runes {
+		if unicode.IsControl(r) {
+			continue
+		}
+		if t.maxLength > 0 && len(t.text)-(e-s)+len(accepted) >= t.maxLength {
+			break
+		}
+		if t.filter != nil && !t.filter(r, s+len(accepted)) {
+			continue
+		}
+		accepted = append(accepted, r)
+	}
+	if len(accepted) == 0 {
+		return false
+	}
+
+	t.beginEdit(edit)
+	t.replace(s, e, accepted)
+	return true
+}
+
+// replace replaces the range [s, e) with runes and puts the caret after them.
+func (t *TextInput) replace(s, e int, runes []rune) {
+	text := make([]rune, 0, len(t.text)-(e-s)+len(runes))
+	text = append(text, t.text[:s]...)
+	text = append(text, runes...)
+	text = append(text, t.text[e:]...)
+	t.text = text
+	t.caret = s + len(runes)
+	t.anchor = t.caret
+}
+
+func (t *TextInput) deleteBackward(word bool) bool {
+	s, e := t.Selection()
+	if s == e {
+		if s == 0 {
+			return false
+		}
+		if word {
+			s = t.wordLeft(s)
+		} else {
+			s--
+		}
+	}
+	t.beginEdit(textEditOther)
+	t.replace(s, e, nil)
+	return true
+}
+
+func (t *TextInput) deleteForward(word bool) bool {
+	s, e := t.Selection()
+	if s == e {
+		if e == len(t.text) {
+			return false
+		}
+		if word {
+			e = t.wordRight(e)
+		} else {
+			e++
+		}
+	}
+	t.beginEdit(textEditOther)
+	t.replace(s, e, nil)
+	return true
+}
+
+func (t *TextInput) moveHorizontally(dir int, word, shift bool) {
+	if s, e := t.Selection(); s != e && !shift && !word {
+		// Collapse the selection to its side.
+		if dir < 0 {
+			t.moveTo(s, false)
+		} else {
+			t.moveTo(e, false)
+		}
+		return
+	}
+
+	pos := t.caret
+	switch {
+	case word && dir < 0:
+		pos = t.wordLeft(pos)
+	case word:
+		pos = t.wordRight(pos)
+	default:
+		pos += dir
+	}
+	t.moveTo(pos, shift)
+}
+
+// moveTo moves the caret. With extend, the selection is extended from the anchor.
+func (t *TextInput) moveTo(pos int, extend bool) {
+	anchor := t.anchor
+	if !extend {
+		anchor = pos
+	}
+	t.Select(anchor, pos)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wordLeft returns the start of the word before pos.
+func (t *TextInput) wordLeft(pos int) int {
+	for pos > 0 && !isWordRune(t.text[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordRune(t.text[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// wordRight returns the end of the word after pos.
+func (t *TextInput) wordRight(pos int) int {
+	for pos < len(t.text) && !isWordRune(t.text[pos]) {
+		pos++
+	}
+	for pos < len(t.text) && isWordRune(t.text[pos]) {
+		pos++
+	}
+	return pos
+}
@@ -0,0 +1,447 @@
+package nyuuryoku
+
+import (
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// GamepadProfile describes a gamepad connected to a VirtualGamepad.
+type GamepadProfile struct {
+	Name           string
+	SDLID          string
+	ButtonCount    int
+	AxisCount      int
+	StandardLayout bool
+}
+
+// StandardGamepadProfile is a profile of a typical gamepad with the standard layout.
+var StandardGamepadProfile = GamepadProfile{
+	Name:           "Virtual Gamepad",
+	SDLID:          "00000000000000000000000000000000",
+	ButtonCount:    int(ebiten.StandardGamepadButtonMax) + 1,
+	AxisCount:      int(ebiten.StandardGamepadAxisMax) + 1,
+	StandardLayout: true,
+}
+
+// VirtualGamepad is a set of gamepads driven by code instead of physical devices.
+//
+// Connect, Disconnect and the button and axis setters change the state that becomes visible at the next Tick.
+// Tick advances one frame in the same way as inpututil does before every Update,
+// so connection events, just pressed and just released buttons and press durations always agree with each other.
+//
+// Raw buttons and standard buttons are independent of each other,
+// because the mapping between them depends on the device.
+type VirtualGamepad struct {
+	devices map[ebiten.GamepadID]*virtualGamepadDevice
+	states  map[ebiten.GamepadID]*virtualGamepadState
+
+	ids     map[ebiten.GamepadID]struct{}
+	prevIDs map[ebiten.GamepadID]struct{}
+
+	buttonDurations     map[ebiten.GamepadID][]int
+	prevButtonDurations map[ebiten.GamepadID][]int
+
+	standardButtonDurations     map[ebiten.GamepadID][]int
+	prevStandardButtonDurations map[ebiten.GamepadID][]int
+}
+
+type virtualGamepadDevice struct {
+	profile         GamepadProfile
+	buttons         []bool
+	standardButtons []bool
+	axes            []float64
+	standardAxes    []float64
+}
+
+type virtualGamepadState struct {
+	profile      GamepadProfile
+	axes         []float64
+	standardAxes []float64
+}
+
+func NewVirtualGamepad() *VirtualGamepad {
+	return &VirtualGamepad{
+		devices: make(map[ebiten.GamepadID]*virtualGamepadDevice),
+		states:  make(map[ebiten.GamepadID]*virtualGamepadState),
+
+		ids:     make(map[ebiten.GamepadID]struct{}),
+		prevIDs: make(map[ebiten.GamepadID]struct{}),
+
+		buttonDurations:     make(map[ebiten.GamepadID][]int),
+		prevButtonDurations: make(map[ebiten.GamepadID][]int),
+
+		standardButtonDurations:     make(map[ebiten.GamepadID][]int),
+		prevStandardButtonDurations: make(map[ebiten.GamepadID][]int),
+	}
+}
+
+// Attach makes g read all of its input from v.
+// Call NewGamepadSetter(g).SetDefault() to switch g back to the real gamepads.
+func (v *VirtualGamepad) Attach(g *Gamepad) {
+	s := NewGamepadSetter(g)
+	s.SetAppendIDsFunc(v.appendIDs)
+	s.SetAxisCountFunc(v.axisCount)
+	s.SetAxisValueFunc(v.axisValue)
+	s.SetButtonCountFunc(v.buttonCount)
+	s.SetNameFunc(v.name)
+	s.SetSDLIDFunc(v.sdlID)
+	s.SetIsButtonPressedFunc(v.isButtonPressed)
+	s.SetIsStandardAxisAvailableFunc(v.isStandardAxisAvailable)
+	s.SetIsStandardButtonAvailableFunc(v.isStandardButtonAvailable)
+	s.SetIsStandardButtonPressedFunc(v.isStandardButtonPressed)
+	s.SetIsStandardLayoutAvailableFunc(v.isStandardLayoutAvailable)
+	s.SetStandardAxisValueFunc(v.standardAxisValue)
+	s.SetAppendJustConnectedIDsFunc(v.appendJustConnectedIDs)
+	s.SetAppendJustPressedButtonsFunc(v.appendJustPressedButtons)
+	s.SetAppendJustPressedStandardButtonsFunc(v.appendJustPressedStandardButtons)
+	s.SetAppendJustReleasedButtonsFunc(v.appendJustReleasedButtons)
+	s.SetAppendJustReleasedStandardButtonsFunc(v.appendJustReleasedStandardButtons)
+	s.SetAppendPressedButtonsFunc(v.appendPressedButtons)
+	s.SetAppendPressedStandardButtonsFunc(v.appendPressedStandardButtons)
+	s.SetButtonPressDurationFunc(v.buttonPressDuration)
+	s.SetIsButtonJustPressedFunc(v.isButtonJustPressed)
+	s.SetIsButtonJustReleasedFunc(v.isButtonJustReleased)
+	s.SetIsJustDisconnectedFunc(v.isJustDisconnected)
+	s.SetIsStandardButtonJustPressedFunc(v.isStandardButtonJustPressed)
+	s.SetIsStandardButtonJustReleasedFunc(v.isStandardButtonJustReleased)
+	s.SetStandardButtonPressDurationFunc(v.standardButtonPressDuration)
+}
+
+// Connect plugs in a new gamepad from the next Tick and returns its ID.
+// Like Ebitengine, the smallest ID that is not in use is assigned.
+func (v *VirtualGamepad) Connect(profile GamepadProfile) ebiten.GamepadID {
+	id := ebiten.GamepadID(0)
+	for v.isIDInUse(id) {
+		id++
+	}
+	v.connectWithID(id, profile)
+	return id
+}
+
+// connectWithID plugs in a gamepad with the given ID from the next Tick.
+// If id is already connected, the gamepad is replaced with a new one whose buttons are all released.
+func (v *VirtualGamepad) connectWithID(id ebiten.GamepadID, profile GamepadProfile) {
+	v.devices[id] = &virtualGamepadDevice{
+		profile:         profile,
+		buttons:         make([]bool, ebiten.GamepadButtonMax+1),
+		standardButtons: make([]bool, ebiten.StandardGamepadButtonMax+1),
+		axes:            make([]float64, max(profile.AxisCount, 0)),
+		standardAxes:    make([]float64, ebiten.StandardGamepadAxisMax+1),
+	}
+}
+
+// Disconnect unplugs the gamepad id from the next Tick.
+func (v *VirtualGamepad) Disconnect(id ebiten.GamepadID) {
+	delete(v.devices, id)
+}
+
+// PressButton holds the raw button down from the next Tick.
+func (v *VirtualGamepad) PressButton(id ebiten.GamepadID, button ebiten.GamepadButton) {
+	v.setButton(id, button, true)
+}
+
+// ReleaseButton lets the raw button go from the next Tick.
+func (v *VirtualGamepad) ReleaseButton(id ebiten.GamepadID, button ebiten.GamepadButton) {
+	v.setButton(id, button, false)
+}
+
+// PressStandardButton holds the standard button down from the next Tick.
+func (v *VirtualGamepad) PressStandardButton(id ebiten.GamepadID, button ebiten.StandardGamepadButton) {
+	v.setStandardButton(id, button, true)
+}
+
+// ReleaseStandardButton lets the standard button go from the next Tick.
+func (v *VirtualGamepad) ReleaseStandardButton(id ebiten.GamepadID, button ebiten.StandardGamepadButton) {
+	v.setStandardButton(id, button, false)
+}
+
+// SetAxisValue sets the raw axis value from the next Tick.
+func (v *VirtualGamepad) SetAxisValue(id ebiten.GamepadID, axis int, value float64) {
+	d, ok := v.devices[id]
+	if !ok || axis < 0 || axis >= len(d.axes) {
+		return
+	}
+	d.axes[axis] = value
+}
+
+// SetStandardAxisValue sets the standard axis value from the next Tick.
+func (v *VirtualGamepad) SetStandardAxisValue(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis, value float64) {
+	d, ok := v.devices[id]
+	if !ok || axis < 0 || axis > ebiten.StandardGamepadAxisMax {
+		return
+	}
+	d.standardAxes[axis] = value
+}
+
+// Tick advances all the gamepads by one frame.
+func (v *VirtualGamepad) Tick() {
+	clear(v.prevIDs)
+	for id := range v.ids {
+		v.prevIDs[id] = struct{}{}
+	}
+
+	clear(v.prevButtonDurations)
+	for id, ds := range v.buttonDurations {
+		v.prevButtonDurations[id] = append([]int{}, ds...)
+	}
+
+	clear(v.prevStandardButtonDurations)
+	for id, ds := range v.standardButtonDurations {
+		v.prevStandardButtonDurations[id] = append([]int{}, ds...)
+	}
+
+	clear(v.ids)
+	for id, d := range v.devices {
+		v.ids[id] = struct{}{}
+
+		v.states[id] = &virtualGamepadState{
+			profile:      d.profile,
+			axes:         append([]float64{}, d.axes...),
+			standardAxes: append([]float64{}, d.standardAxes...),
+		}
+
+		if _, ok := v.buttonDurations[id]; !ok {
+			v.buttonDurations[id] = make([]int, ebiten.GamepadButtonMax+1)
+		}
+		for b, ds := range v.buttonDurations[id] {
+			if d.buttons[b] && b < d.profile.ButtonCount {
+				v.buttonDurations[id][b] = ds + 1
+			} else {
+				v.buttonDurations[id][b] = 0
+			}
+		}
+
+		if _, ok := v.standardButtonDurations[id]; !ok {
+			v.standardButtonDurations[id] = make([]int, ebiten.StandardGamepadButtonMax+1)
+		}
+		for b, ds := range v.standardButtonDurations[id] {
+			if d.standardButtons[b] && d.profile.StandardLayout {
+				v.standardButtonDurations[id][b] = ds + 1
+			} else {
+				v.standardButtonDurations[id][b] = 0
+			}
+		}
+	}
+
+	for id := range v.states {
+		if _, ok := v.ids[id]; !ok {
+			delete(v.states, id)
+			delete(v.buttonDurations, id)
+			delete(v.standardButtonDurations, id)
+		}
+	}
+}
+
+func (v *VirtualGamepad) isIDInUse(id ebiten.GamepadID) bool {
+	if _, ok := v.devices[id]; ok {
+		return true
+	}
+	if _, ok := v.ids[id]; ok {
+		return true
+	}
+	return false
+}
+
+func (v *VirtualGamepad) setButton(id ebiten.GamepadID, button ebiten.GamepadButton, pressed bool) {
+	d, ok := v.devices[id]
+	if !ok || button < 0 || button > ebiten.GamepadButtonMax {
+		return
+	}
+	d.buttons[button] = pressed
+}
+
+func (v *VirtualGamepad) setStandardButton(id ebiten.GamepadID, button ebiten.StandardGamepadButton, pressed bool) {
+	d, ok := v.devices[id]
+	if !ok || button < 0 || button > ebiten.StandardGamepadButtonMax {
+		return
+	}
+	d.standardButtons[button] = pressed
+}
+
+func (v *VirtualGamepad) appendIDs(gamepadIDs []ebiten.GamepadID) []ebiten.GamepadID {
+	origLen := len(gamepadIDs)
+	for id := range v.ids {
+		gamepadIDs = append(gamepadIDs, id)
+	}
+	slices.Sort(gamepadIDs[origLen:])
+	return gamepadIDs
+}
+
+func (v *VirtualGamepad) axisCount(id ebiten.GamepadID) int {
+	s, ok := v.states[id]
+	if !ok {
+		return 0
+	}
+	return len(s.axes)
+}
+
+func (v *VirtualGamepad) axisValue(id ebiten.GamepadID, axis int) float64 {
+	s, ok := v.states[id]
+	if !ok || axis < 0 || axis >= len(s.axes) {
+		return 0
+	}
+	return s.axes[axis]
+}
+
+func (v *VirtualGamepad) buttonCount(id ebiten.GamepadID) int {
+	s, ok := v.states[id]
+	if !ok {
+		return 0
+	}
+	return s.profile.ButtonCount
+}
+
+func (v *VirtualGamepad) name(id ebiten.GamepadID) string {
+	s, ok := v.states[id]
+	if !ok {
+		return ""
+	}
+	return s.profile.Name
+}
+
+func (v *VirtualGamepad) sdlID(id ebiten.GamepadID) string {
+	s, ok := v.states[id]
+	if !ok {
+		return ""
+	}
+	return s.profile.SDLID
+}
+
+func (v *VirtualGamepad) isButtonPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return v.buttonPressDuration(id, button) > 0
+}
+
+func (v *VirtualGamepad) isStandardAxisAvailable(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) bool {
+	return v.isStandardLayoutAvailable(id) && axis >= 0 && axis <= ebiten.StandardGamepadAxisMax
+}
+
+func (v *VirtualGamepad) isStandardButtonAvailable(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return v.isStandardLayoutAvailable(id) && button >= 0 && button <= ebiten.StandardGamepadButtonMax
+}
+
+func (v *VirtualGamepad) isStandardButtonPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return v.standardButtonPressDuration(id, button) > 0
+}
+
+func (v *VirtualGamepad) isStandardLayoutAvailable(id ebiten.GamepadID) bool {
+	s, ok := v.states[id]
+	if !ok {
+		return false
+	}
+	return s.profile.StandardLayout
+}
+
+func (v *VirtualGamepad) standardAxisValue(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64 {
+	if !v.isStandardAxisAvailable(id, axis) {
+		return 0
+	}
+	return v.states[id].standardAxes[axis]
+}
+
+func (v *VirtualGamepad) appendJustConnectedIDs(gamepadIDs []ebiten.GamepadID) []ebiten.GamepadID {
+	origLen := len(gamepadIDs)
+	for id := range v.ids {
+		if _, ok := v.prevIDs[id]; !ok {
+			gamepadIDs = append(gamepadIDs, id)
+		}
+	}
+	slices.Sort(gamepadIDs[origLen:])
+	return gamepadIDs
+}
+
+func (v *VirtualGamepad) appendJustPressedButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton {
+	for b, d := range v.buttonDurations[id] {
+		if d == 1 {
+			buttons = append(buttons, ebiten.GamepadButton(b))
+		}
+	}
+	return buttons
+}
+
+func (v *VirtualGamepad) appendJustPressedStandardButtons(id ebiten.GamepadID, buttons []ebiten.StandardGamepadButton) []ebiten.StandardGamepadButton {
+	for b, d := range v.standardButtonDurations[id] {
+		if d == 1 {
+			buttons = append(buttons, ebiten.StandardGamepadButton(b))
+		}
+	}
+	return buttons
+}
+
+func (v *VirtualGamepad) appendJustReleasedButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton {
+	for b := ebiten.GamepadButton(0); b <= ebiten.GamepadButtonMax; b++ {
+		if v.isButtonJustReleased(id, b) {
+			buttons = append(buttons, b)
+		}
+	}
+	return buttons
+}
+
+func (v *VirtualGamepad) appendJustReleasedStandardButtons(id ebiten.GamepadID, buttons []ebiten.StandardGamepadButton) []ebiten.StandardGamepadButton {
+	for b := ebiten.StandardGamepadButton(0); b <= ebiten.StandardGamepadButtonMax; b++ {
+		if v.isStandardButtonJustReleased(id, b) {
+			buttons = append(buttons, b)
+		}
+	}
+	return buttons
+}
+
+func (v *VirtualGamepad) appendPressedButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton {
+	for b, d := range v.buttonDurations[id] {
+		if d > 0 {
+			buttons = append(buttons, ebiten.GamepadButton(b))
+		}
+	}
+	return buttons
+}
+
+func (v *VirtualGamepad) appendPressedStandardButtons(id ebiten.GamepadID, buttons []ebiten.StandardGamepadButton) []ebiten.StandardGamepadButton {
+	for b, d := range v.standardButtonDurations[id] {
+		if d > 0 {
+			buttons = append(buttons, ebiten.StandardGamepadButton(b))
+		}
+	}
+	return buttons
+}
+
+func (v *VirtualGamepad) buttonPressDuration(id ebiten.GamepadID, button ebiten.GamepadButton) int {
+	return durationAt(v.buttonDurations[id], int(button))
+}
+
+func (v *VirtualGamepad) isButtonJustPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return v.buttonPressDuration(id, button) == 1
+}
+
+func (v *VirtualGamepad) isButtonJustReleased(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	current := durationAt(v.buttonDurations[id], int(button))
+	prev := durationAt(v.prevButtonDurations[id], int(button))
+	return current == 0 && prev > 0
+}
+
+func (v *VirtualGamepad) isJustDisconnected(id ebiten.GamepadID) bool {
+	_, prev := v.prevIDs[id]
+	_, current := v.ids[id]
+	return prev && !current
+}
+
+func (v *VirtualGamepad) isStandardButtonJustPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return v.standardButtonPressDuration(id, button) == 1
+}
+
+func (v *VirtualGamepad) isStandardButtonJustReleased(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	current := durationAt(v.standardButtonDurations[id], int(button))
+	prev := durationAt(v.prevStandardButtonDurations[id], int(button))
+	return current == 0 && prev > 0
+}
+
+func (v *VirtualGamepad) standardButtonPressDuration(id ebiten.GamepadID, button ebiten.StandardGamepadButton) int {
+	return durationAt(v.standardButtonDurations[id], int(button))
+}
+
+// durationAt returns durations[i], or 0 if i is out of range.
+func durationAt(durations []int, i int) int {
+	if i < 0 || i >= len(durations) {
+		return 0
+	}
+	return durations[i]
+}
@@ -0,0 +1,220 @@
+package nyuuryoku
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// VirtualKeyboard is a keyboard driven by code instead of a physical device.
+//
+// Press, Release and TypeRunes change the state that becomes visible at the next Tick.
+// Tick advances one frame in the same way as inpututil does before every Update,
+// so pressed, just pressed, just released and press durations always agree with each other.
+//
+// As in Ebitengine, KeyShift, KeyControl, KeyAlt and KeyMeta are pressed while their left or right key is pressed.
+type VirtualKeyboard struct {
+	pressed       []bool
+	durations     []int
+	prevDurations []int
+	pendingChars  []rune
+	inputChars    []rune
+	names         map[ebiten.Key]string
+}
+
+func NewVirtualKeyboard() *VirtualKeyboard {
+	return &VirtualKeyboard{
+		pressed:       make([]bool, ebiten.KeyMax+1),
+		durations:     make([]int, ebiten.KeyMax+1),
+		prevDurations: make([]int, ebiten.KeyMax+1),
+		names:         make(map[ebiten.Key]string),
+	}
+}
+
+// Attach makes k read all of its input from v.
+// Call NewKeyboardSetter(k).SetDefault() to switch k back to the real keyboard.
+func (v *VirtualKeyboard) Attach(k *Keyboard) {
+	s := NewKeyboardSetter(k)
+	s.SetIsPressedFunc(v.isPressed)
+	s.SetIsJustPressedFunc(v.isJustPressed)
+	s.SetIsJustReleasedFunc(v.isJustReleased)
+	s.SetPressDurationFunc(v.pressDuration)
+	s.SetNameFunc(v.name)
+	s.SetAppendPressedFunc(v.appendPressed)
+	s.SetAppendJustPressedFunc(v.appendJustPressed)
+	s.SetAppendJustReleasedFunc(v.appendJustReleased)
+	s.SetAppendInputCharsFunc(v.appendInputChars)
+}
+
+// sidedModifierKeys maps the modifier keys that Ebitengine reports as pressed while either side is pressed
+// to their left and right keys.
+var sidedModifierKeys = map[ebiten.Key][2]ebiten.Key{
+	ebiten.KeyAlt:     {ebiten.KeyAltLeft, ebiten.KeyAltRight},
+	ebiten.KeyControl: {ebiten.KeyControlLeft, ebiten.KeyControlRight},
+	ebiten.KeyShift:   {ebiten.KeyShiftLeft, ebiten.KeyShiftRight},
+	ebiten.KeyMeta:    {ebiten.KeyMetaLeft, ebiten.KeyMetaRight},
+}
+
+// Press holds key down from the next Tick.
+// Pressing KeyShift, KeyControl, KeyAlt or KeyMeta presses its left key, unless either side is already pressed.
+func (v *VirtualKeyboard) Press(key ebiten.Key) {
+	if !v.isValid(key) {
+		return
+	}
+	if sides, ok := sidedModifierKeys[key]; ok {
+		if v.pressed[sides[0]] || v.pressed[sides[1]] {
+			return
+		}
+		key = sides[0]
+	}
+	v.pressed[key] = true
+}
+
+// Release lets key go from the next Tick.
+// Releasing KeyShift, KeyControl, KeyAlt or KeyMeta releases both its left and right keys.
+func (v *VirtualKeyboard) Release(key ebiten.Key) {
+	if !v.isValid(key) {
+		return
+	}
+	if sides, ok := sidedModifierKeys[key]; ok {
+		v.pressed[sides[0]] = false
+		v.pressed[sides[1]] = false
+		return
+	}
+	v.pressed[key] = false
+}
+
+// ReleaseAll lets every key go from the next Tick.
+func (v *VirtualKeyboard) ReleaseAll() {
+	clear(v.pressed)
+}
+
+// TypeRunes queues runes to be reported by AppendInputChars during the next tick only.
+func (v *VirtualKeyboard) TypeRunes(runes ...rune) {
+	v.pendingChars = append(v.pendingChars, runes...)
+}
+
+// SetName overrides the name reported for key.
+// Without an override, names follow the US keyboard layout.
+func (v *VirtualKeyboard) SetName(key ebiten.Key, name string) {
+	v.names[key] = name
+}
+
+// Tick advances the keyboard by one frame.
+func (v *VirtualKeyboard) Tick() {
+	copy(v.prevDurations, v.durations)
+	for k := range v.durations {
+		if v.isDown(ebiten.Key(k)) {
+			v.durations[k]++
+		} else {
+			v.durations[k] = 0
+		}
+	}
+
+	v.inputChars = append(v.inputChars[:0], v.pendingChars...)
+	v.pendingChars = v.pendingChars[:0]
+}
+
+// isDown reports whether key is held down by Press, including the modifier keys of either side.
+func (v *VirtualKeyboard) isDown(key ebiten.Key) bool {
+	if sides, ok := sidedModifierKeys[key]; ok {
+		return v.pressed[sides[0]] || v.pressed[sides[1]]
+	}
+	return v.pressed[key]
+}
+
+func (v *VirtualKeyboard) isValid(key ebiten.Key) bool {
+	return key >= 0 && key <= ebiten.KeyMax
+}
+
+func (v *VirtualKeyboard) isPressed(key ebiten.Key) bool {
+	return v.pressDuration(key) > 0
+}
+
+func (v *VirtualKeyboard) isJustPressed(key ebiten.Key) bool {
+	return v.pressDuration(key) == 1
+}
+
+func (v *VirtualKeyboard) isJustReleased(key ebiten.Key) bool {
+	if !v.isValid(key) {
+		return false
+	}
+	return v.durations[key] == 0 && v.prevDurations[key] > 0
+}
+
+func (v *VirtualKeyboard) pressDuration(key ebiten.Key) int {
+	if !v.isValid(key) {
+		return 0
+	}
+	return v.durations[key]
+}
+
+func (v *VirtualKeyboard) name(key ebiten.Key) string {
+	if name, ok := v.names[key]; ok {
+		return name
+	}
+	return usLayoutKeyName(key)
+}
+
+func (v *VirtualKeyboard) appendPressed(keys []ebiten.Key) []ebiten.Key {
+	for k, d := range v.durations {
+		if d > 0 {
+			keys = append(keys, ebiten.Key(k))
+		}
+	}
+	return keys
+}
+
+func (v *VirtualKeyboard) appendJustPressed(keys []ebiten.Key) []ebiten.Key {
+	for k, d := range v.durations {
+		if d == 1 {
+			keys = append(keys, ebiten.Key(k))
+		}
+	}
+	return keys
+}
+
+func (v *VirtualKeyboard) appendJustReleased(keys []ebiten.Key) []ebiten.Key {
+	for k := range v.durations {
+		if v.isJustReleased(ebiten.Key(k)) {
+			keys = append(keys, ebiten.Key(k))
+		}
+	}
+	return keys
+}
+
+func (v *VirtualKeyboard) appendInputChars(runes []rune) []rune {
+	return append(runes, v.inputChars...)
+}
+
+var usLayoutSymbolNames = map[ebiten.Key]string{
+	ebiten.KeyBackquote:    "`",
+	ebiten.KeyBackslash:    `\`,
+	ebiten.KeyBracketLeft:  "[",
+	ebiten.KeyBracketRight: "]",
+	ebiten.KeyComma:        ",",
+	ebiten.KeyEqual:        "=",
+	ebiten.KeyMinus:        "-",
+	ebiten.KeyPeriod:       ".",
+	ebiten.KeyQuote:        "'",
+	ebiten.KeySemicolon:    ";",
+	ebiten.KeySlash:        "/",
+}
+
+// usLayoutKeyName returns what ebiten.KeyName returns for key on a US keyboard.
+// Like ebiten.KeyName, it returns an empty string for keys without a printable name.
+func usLayoutKeyName(key ebiten.Key) string {
+	if name, ok := usLayoutSymbolNames[key]; ok {
+		return name
+	}
+
+	s := key.String()
+	if len(s) == 1 {
+		return strings.ToLower(s)
+	}
+	if digit, ok := strings.CutPrefix(s, "Digit"); ok {
+		return digit
+	}
+
+	return ""
+}
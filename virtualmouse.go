@@ -0,0 +1,130 @@
+package nyuuryoku
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// VirtualMouse is a mouse driven by code instead of a physical device.
+//
+// MoveTo, MoveBy, Press, Release and Scroll change the state that becomes visible at the next Tick.
+// Wheel offsets are accumulated until the next Tick and are reported for that tick only,
+// in the same way as ebiten.Wheel.
+type VirtualMouse struct {
+	x, y           int
+	nextX, nextY   int
+	wheelX, wheelY float64
+	pendingWheelX  float64
+	pendingWheelY  float64
+	pressed        []bool
+	durations      []int
+	prevDurations  []int
+}
+
+func NewVirtualMouse() *VirtualMouse {
+	return &VirtualMouse{
+		pressed:       make([]bool, ebiten.MouseButtonMax+1),
+		durations:     make([]int, ebiten.MouseButtonMax+1),
+		prevDurations: make([]int, ebiten.MouseButtonMax+1),
+	}
+}
+
+// Attach makes m read all of its input from v.
+// Call NewMouseSetter(m).SetDefault() to switch m back to the real mouse.
+func (v *VirtualMouse) Attach(m *Mouse) {
+	s := NewMouseSetter(m)
+	s.SetCursorPositionFunc(v.cursorPosition)
+	s.SetIsPressedFunc(v.isPressed)
+	s.SetIsJustPressedFunc(v.isJustPressed)
+	s.SetIsJustReleasedFunc(v.isJustReleased)
+	s.SetPressDurationFunc(v.pressDuration)
+	s.SetWheelFunc(v.wheel)
+}
+
+// MoveTo moves the cursor to (x, y) from the next Tick.
+func (v *VirtualMouse) MoveTo(x, y int) {
+	v.nextX, v.nextY = x, y
+}
+
+// MoveBy moves the cursor by (dx, dy) from the next Tick.
+func (v *VirtualMouse) MoveBy(dx, dy int) {
+	v.nextX += dx
+	v.nextY += dy
+}
+
+// Press holds button down from the next Tick.
+func (v *VirtualMouse) Press(button ebiten.MouseButton) {
+	if !v.isValid(button) {
+		return
+	}
+	v.pressed[button] = true
+}
+
+// Release lets button go from the next Tick.
+func (v *VirtualMouse) Release(button ebiten.MouseButton) {
+	if !v.isValid(button) {
+		return
+	}
+	v.pressed[button] = false
+}
+
+// ReleaseAll lets every button go from the next Tick.
+func (v *VirtualMouse) ReleaseAll() {
+	clear(v.pressed)
+}
+
+// Scroll adds (dx, dy) to the wheel offsets reported during the next tick.
+func (v *VirtualMouse) Scroll(dx, dy float64) {
+	v.pendingWheelX += dx
+	v.pendingWheelY += dy
+}
+
+// Tick advances the mouse by one frame.
+func (v *VirtualMouse) Tick() {
+	v.x, v.y = v.nextX, v.nextY
+
+	v.wheelX, v.wheelY = v.pendingWheelX, v.pendingWheelY
+	v.pendingWheelX, v.pendingWheelY = 0, 0
+
+	copy(v.prevDurations, v.durations)
+	for b := range v.durations {
+		if v.pressed[b] {
+			v.durations[b]++
+		} else {
+			v.durations[b] = 0
+		}
+	}
+}
+
+func (v *VirtualMouse) isValid(button ebiten.MouseButton) bool {
+	return button >= 0 && button <= ebiten.MouseButtonMax
+}
+
+func (v *VirtualMouse) cursorPosition() (int, int) {
+	return v.x, v.y
+}
+
+func (v *VirtualMouse) isPressed(button ebiten.MouseButton) bool {
+	return v.pressDuration(button) > 0
+}
+
+func (v *VirtualMouse) isJustPressed(button ebiten.MouseButton) bool {
+	return v.pressDuration(button) == 1
+}
+
+func (v *VirtualMouse) isJustReleased(button ebiten.MouseButton) bool {
+	if !v.isValid(button) {
+		return false
+	}
+	return v.durations[button] == 0 && v.prevDurations[button] > 0
+}
+
+func (v *VirtualMouse) pressDuration(button ebiten.MouseButton) int {
+	if !v.isValid(button) {
+		return 0
+	}
+	return v.durations[button]
+}
+
+func (v *VirtualMouse) wheel() (float64, float64) {
+	return v.wheelX, v.wheelY
+}
@@ -0,0 +1,267 @@
+package nyuuryoku
+
+import (
+	"image"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// TouchStroke is a path that a finger follows while it touches the screen.
+//
+// The finger touches down at the first point, moves along the points taking the same number of ticks for each segment,
+// and reaches the last point at its Frames-th tick. It is lifted at the tick after that.
+type TouchStroke struct {
+	Points []image.Point
+	Frames int
+}
+
+func (s TouchStroke) positionAt(frame int) image.Point {
+	if len(s.Points) == 0 {
+		return image.Point{}
+	}
+	if len(s.Points) == 1 || s.Frames <= 1 {
+		return s.Points[0]
+	}
+
+	t := float64(frame) / float64(s.Frames-1) * float64(len(s.Points)-1)
+	i := int(t)
+	if i >= len(s.Points)-1 {
+		return s.Points[len(s.Points)-1]
+	}
+
+	from, to := s.Points[i], s.Points[i+1]
+	rate := t - float64(i)
+	return image.Point{
+		X: from.X + int(math.Round(float64(to.X-from.X)*rate)),
+		Y: from.Y + int(math.Round(float64(to.Y-from.Y)*rate)),
+	}
+}
+
+type touchScript struct {
+	id     ebiten.TouchID
+	stroke TouchStroke
+	frame  int
+}
+
+// VirtualTouch is a touch screen driven by code instead of a physical device.
+//
+// Begin, Move, End and the gesture scripts change the state that becomes visible at the next Tick.
+// Tick advances one frame in the same way as inpututil does before every Update,
+// so just pressed and just released IDs, press durations and previous tick positions always agree with each other.
+type VirtualTouch struct {
+	nextID  ebiten.TouchID
+	touches map[ebiten.TouchID]image.Point
+	scripts []*touchScript
+
+	positions     map[ebiten.TouchID]image.Point
+	prevPositions map[ebiten.TouchID]image.Point
+	durations     map[ebiten.TouchID]int
+	prevDurations map[ebiten.TouchID]int
+}
+
+func NewVirtualTouch() *VirtualTouch {
+	return &VirtualTouch{
+		touches:       make(map[ebiten.TouchID]image.Point),
+		positions:     make(map[ebiten.TouchID]image.Point),
+		prevPositions: make(map[ebiten.TouchID]image.Point),
+		durations:     make(map[ebiten.TouchID]int),
+		prevDurations: make(map[ebiten.TouchID]int),
+	}
+}
+
+// Attach makes t read all of its input from v.
+// Call NewTouchSetter(t).SetDefault() to switch t back to the real touch screen.
+func (v *VirtualTouch) Attach(t *Touch) {
+	s := NewTouchSetter(t)
+	s.SetAppendIDsFunc(v.appendIDs)
+	s.SetPositionFunc(v.position)
+	s.SetAppendJustPressedIDsFunc(v.appendJustPressedIDs)
+	s.SetAppendJustReleasedIDsFunc(v.appendJustReleasedIDs)
+	s.SetIsJustReleasedFunc(v.isJustReleased)
+	s.SetPositionInPreviousTickFunc(v.positionInPreviousTick)
+	s.SetPressDurationFunc(v.pressDuration)
+}
+
+// Begin touches the screen at (x, y) from the next Tick and returns the new touch ID.
+// Touch IDs are never reused.
+func (v *VirtualTouch) Begin(x, y int) ebiten.TouchID {
+	id := v.nextID
+	v.nextID++
+	v.touches[id] = image.Pt(x, y)
+	return id
+}
+
+// Move moves the touch id to (x, y) from the next Tick.
+func (v *VirtualTouch) Move(id ebiten.TouchID, x, y int) {
+	if _, ok := v.touches[id]; !ok {
+		return
+	}
+	v.touches[id] = image.Pt(x, y)
+}
+
+// End lifts the touch id from the next Tick.
+func (v *VirtualTouch) End(id ebiten.TouchID) {
+	delete(v.touches, id)
+}
+
+// Play starts a gesture made of one stroke per finger at the next Tick and returns the touch IDs of the fingers.
+// All the fingers touch down at the same tick.
+func (v *VirtualTouch) Play(strokes ...TouchStroke) []ebiten.TouchID {
+	ids := make([]ebiten.TouchID, 0, len(strokes))
+	for _, s := range strokes {
+		p := s.positionAt(0)
+		id := v.Begin(p.X, p.Y)
+		v.scripts = append(v.scripts, &touchScript{id: id, stroke: s})
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// IsPlaying reports whether any gesture started by Play is still in progress.
+func (v *VirtualTouch) IsPlaying() bool {
+	return len(v.scripts) > 0
+}
+
+// Tap touches (x, y) for one tick.
+func (v *VirtualTouch) Tap(x, y int) ebiten.TouchID {
+	return v.LongPress(x, y, 1)
+}
+
+// LongPress touches (x, y) for the given number of ticks.
+func (v *VirtualTouch) LongPress(x, y int, frames int) ebiten.TouchID {
+	return v.Play(TouchStroke{Points: []image.Point{image.Pt(x, y)}, Frames: frames})[0]
+}
+
+// Swipe moves a finger straight from one point to another over the given number of ticks.
+func (v *VirtualTouch) Swipe(from, to image.Point, frames int) ebiten.TouchID {
+	return v.Play(TouchStroke{Points: []image.Point{from, to}, Frames: frames})[0]
+}
+
+// Pinch moves two fingers placed on opposite sides of center horizontally,
+// changing the distance between them from fromDistance to toDistance over the given number of ticks.
+func (v *VirtualTouch) Pinch(center image.Point, fromDistance, toDistance float64, frames int) []ebiten.TouchID {
+	return v.Play(pinchStrokes(center, fromDistance, toDistance, 0, 0, frames)...)
+}
+
+// Rotate moves two fingers placed on opposite sides of center at the given distance,
+// turning them around center from fromAngle to toAngle in radians over the given number of ticks.
+func (v *VirtualTouch) Rotate(center image.Point, distance, fromAngle, toAngle float64, frames int) []ebiten.TouchID {
+	return v.Play(pinchStrokes(center, distance, distance, fromAngle, toAngle, frames)...)
+}
+
+// pinchStrokes returns two strokes on opposite sides of center with one point per tick.
+func pinchStrokes(center image.Point, fromDistance, toDistance, fromAngle, toAngle float64, frames int) []TouchStroke {
+	if frames < 1 {
+		frames = 1
+	}
+
+	strokes := []TouchStroke{
+		{Points: make([]image.Point, frames), Frames: frames},
+		{Points: make([]image.Point, frames), Frames: frames},
+	}
+	for i := range frames {
+		rate := 0.0
+		if frames > 1 {
+			rate = float64(i) / float64(frames-1)
+		}
+		r := (fromDistance + (toDistance-fromDistance)*rate) / 2
+		a := fromAngle + (toAngle-fromAngle)*rate
+		dx := int(math.Round(r * math.Cos(a)))
+		dy := int(math.Round(r * math.Sin(a)))
+		strokes[0].Points[i] = image.Pt(center.X-dx, center.Y-dy)
+		strokes[1].Points[i] = image.Pt(center.X+dx, center.Y+dy)
+	}
+
+	return strokes
+}
+
+// Tick advances the gesture scripts and the touch screen by one frame.
+func (v *VirtualTouch) Tick() {
+	v.updateScripts()
+
+	clear(v.prevDurations)
+	maps.Copy(v.prevDurations, v.durations)
+	clear(v.prevPositions)
+	maps.Copy(v.prevPositions, v.positions)
+
+	for id, p := range v.touches {
+		v.durations[id]++
+		v.positions[id] = p
+	}
+	for id := range v.durations {
+		if _, ok := v.touches[id]; !ok {
+			delete(v.durations, id)
+			delete(v.positions, id)
+		}
+	}
+}
+
+func (v *VirtualTouch) updateScripts() {
+	scripts := v.scripts[:0]
+	for _, s := range v.scripts {
+		if _, ok := v.touches[s.id]; !ok {
+			// The touch was ended by End.
+			continue
+		}
+		if s.frame >= s.stroke.Frames {
+			v.End(s.id)
+			continue
+		}
+		p := s.stroke.positionAt(s.frame)
+		v.Move(s.id, p.X, p.Y)
+		s.frame++
+		scripts = append(scripts, s)
+	}
+	clear(v.scripts[len(scripts):])
+	v.scripts = scripts
+}
+
+func (v *VirtualTouch) appendIDs(touches []ebiten.TouchID) []ebiten.TouchID {
+	origLen := len(touches)
+	touches = slices.AppendSeq(touches, maps.Keys(v.durations))
+	slices.Sort(touches[origLen:])
+	return touches
+}
+
+func (v *VirtualTouch) position(id ebiten.TouchID) (int, int) {
+	p := v.positions[id]
+	return p.X, p.Y
+}
+
+func (v *VirtualTouch) appendJustPressedIDs(touchIDs []ebiten.TouchID) []ebiten.TouchID {
+	origLen := len(touchIDs)
+	for id, d := range v.durations {
+		if d == 1 {
+			touchIDs = append(touchIDs, id)
+		}
+	}
+	slices.Sort(touchIDs[origLen:])
+	return touchIDs
+}
+
+func (v *VirtualTouch) appendJustReleasedIDs(touchIDs []ebiten.TouchID) []ebiten.TouchID {
+	origLen := len(touchIDs)
+	for id := range v.prevDurations {
+		if v.isJustReleased(id) {
+			touchIDs = append(touchIDs, id)
+		}
+	}
+	slices.Sort(touchIDs[origLen:])
+	return touchIDs
+}
+
+func (v *VirtualTouch) isJustReleased(id ebiten.TouchID) bool {
+	return v.durations[id] == 0 && v.prevDurations[id] > 0
+}
+
+func (v *VirtualTouch) positionInPreviousTick(id ebiten.TouchID) (int, int) {
+	p := v.prevPositions[id]
+	return p.X, p.Y
+}
+
+func (v *VirtualTouch) pressDuration(id ebiten.TouchID) int {
+	return v.durations[id]
+}